@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/export"
+)
+
+// exportCommand runs the export subcommand, which writes raw clicks to a
+// file or stdout. With -cursor-file it exports incrementally: every run
+// continues where the previous one ended.
+func exportCommand(models database.Models, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", export.NDJSON, "Output format: csv, ndjson or parquet")
+	output := fs.String("o", "-", "Output file, - for stdout")
+	host := fs.String("domain", "", "Host of the short domain of -code (default: the default domain)")
+	code := fs.String("code", "", "Export the clicks of the link with this code")
+	workspace := fs.Int("workspace", 0, "Export the clicks of the links of this workspace")
+	from := fs.String("from", "", "Export clicks at or after this time (RFC 3339 or date in UTC)")
+	to := fs.String("to", "", "Export clicks before this time (RFC 3339 or date in UTC)")
+	cursor := fs.String("cursor", "", "Export the clicks recorded after this cursor of an earlier export")
+	cursorFile := fs.String("cursor-file", "", "Read the cursor from this file and store the next one in it")
+	fs.Parse(args)
+
+	var filter database.ClickFilter
+	filter.WorkspaceID = *workspace
+
+	if *code != "" {
+		domain, err := models.Domains.Default()
+		if *host != "" {
+			domain, err = models.Domains.GetByHost(*host)
+		}
+		if err != nil {
+			return fmt.Errorf("find domain: %w", err)
+		}
+		link, err := models.Links.GetByCode(domain.ID, *code)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("no link with code %q", *code)
+			}
+			return err
+		}
+		filter.LinkID = link.ID
+	}
+
+	var err error
+	if filter.From, err = parseExportTime(*from); err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	if filter.To, err = parseExportTime(*to); err != nil {
+		return fmt.Errorf("-to: %w", err)
+	}
+
+	if *cursorFile != "" && *cursor == "" {
+		b, err := os.ReadFile(*cursorFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		*cursor = strings.TrimSpace(string(b))
+	}
+
+	e, err := export.New(models.Clicks, filter, *cursor)
+	if err != nil {
+		return err
+	}
+
+	n, err := writeExport(e, *format, *output)
+	if err != nil {
+		return err
+	}
+
+	// Only advance the cursor once the clicks are safely written.
+	if *cursorFile != "" {
+		if err := os.WriteFile(*cursorFile, []byte(e.Cursor+"\n"), 0o644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d clicks, cursor %s\n", n, e.Cursor)
+	return nil
+}
+
+// writeExport writes the export to path, replacing the file only when the
+// export is complete, or to stdout when path is "-".
+func writeExport(e *export.Export, format, path string) (int, error) {
+	if path == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		n, err := e.WriteTo(w, format)
+		if err != nil {
+			return n, err
+		}
+		return n, w.Flush()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	n, err := e.WriteTo(w, format)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+
+	return n, os.Rename(f.Name(), path)
+}
+
+// parseExportTime accepts RFC 3339 timestamps and dates in UTC.
+func parseExportTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, errors.New("must be an RFC 3339 timestamp or a date")
+	}
+	return t, nil
+}
@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Timezones of link statistics on hosts without a zoneinfo database.
 
 	"github.com/joybiswas007/linkshort/config"
 	"github.com/joybiswas007/linkshort/internal/database"
@@ -28,6 +31,10 @@ func main() {
 	var cfgFile string
 
 	flag.StringVar(&cfgFile, "conf", "", "Path to configuration file (default: $PWD/.linkshort.yaml)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-conf file] [export [flags]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	flag.Parse()
@@ -52,6 +59,17 @@ func main() {
 		log.Panic(err)
 	}
 
+	if err := database.NewModels(db).Domains.SetDefault(cfg.Domain); err != nil {
+		log.Panic(err)
+	}
+
+	if flag.Arg(0) == "export" {
+		if err := exportCommand(database.NewModels(db), flag.Args()[1:]); err != nil {
+			log.Panic(err)
+		}
+		return
+	}
+
 	buildTime, err := strconv.ParseInt(BuildTime, 10, 64)
 	if err != nil {
 		log.Panicf("Parse failed: could not convert string to int64: %v", err)
@@ -64,44 +82,72 @@ func main() {
 	}
 	cfg.BuildInfo = bi
 
-	srv := server.NewServer(&cfg, db)
-
-	// Create a done channel to signal when the shutdown is complete
-	done := make(chan bool)
-
-	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(srv, done)
-
-	err = srv.ListenAndServe()
-	if err != nil && err != http.ErrServerClosed {
-		panic(fmt.Sprintf("http server error: %s", err))
+	servers, err := server.NewServers(&cfg, db)
+	if err != nil {
+		log.Panic(err)
 	}
 
-	// Wait for the graceful shutdown to complete
-	<-done
-	log.Println("Graceful shutdown complete.")
-}
+	// Start every listener; the first one failing stops the others.
+	serveErr := make(chan error, len(servers.All()))
+	for _, srv := range servers.All() {
+		go func() {
+			log.Printf("Listening on %s", srv.Addr)
+			var err error
+			if srv.TLSConfig != nil {
+				err = srv.ListenAndServeTLS("", "")
+			} else {
+				err = srv.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("http server %s error: %w", srv.Addr, err)
+			}
+		}()
+	}
 
-func gracefulShutdown(apiServer *http.Server, done chan<- bool) {
 	// Listen for interrupt signals (SIGINT, SIGTERM)
 	sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// Block until a signal is received
-	<-sigCtx.Done()
-	log.Println("Shutting down gracefully, press Ctrl+C again to force")
+	// Block until a signal is received or a listener fails
+	select {
+	case <-sigCtx.Done():
+		log.Println("Shutting down gracefully, press Ctrl+C again to force")
+	case err = <-serveErr:
+		log.Printf("Shutting down: %v", err)
+	}
+	stop()
+
+	gracefulShutdown(servers.All())
+
+	// Write the clicks still queued once no more redirects are served.
+	flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := servers.Close(flushCtx); err != nil {
+		log.Printf("Flushing clicks failed: %v", err)
+	}
+	cancel()
+
+	if err != nil {
+		log.Panic(err)
+	}
+	log.Println("Graceful shutdown complete.")
+}
 
+// gracefulShutdown shuts down all servers at once, giving in-flight
+// requests on every listener the same deadline.
+func gracefulShutdown(servers []*http.Server) {
 	// Create a new context for server shutdown timeout
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Attempt graceful shutdown
-	if err := apiServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Go(func() {
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Server %s forced to shutdown with error: %v", srv.Addr, err)
+			}
+		})
 	}
+	wg.Wait()
 
 	log.Println("Server exiting")
-
-	// Notify main goroutine that shutdown is complete
-	done <- true
 }
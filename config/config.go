@@ -4,6 +4,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
@@ -19,16 +20,189 @@ type Config struct {
 	// (e.g., false = "development", true = "production").
 	IsProduction bool `mapstructure:"is_production"`
 
+	// AdminAddr is the address of a separate listener for the management
+	// API, admin UI, metrics and pprof (e.g., "127.0.0.1:8001"). It speaks
+	// plain HTTP and should only be reachable from trusted networks. When it
+	// is empty everything but metrics and pprof is served on Port.
+	AdminAddr string `mapstructure:"admin_addr" validate:"omitempty,hostname_port"`
+
+	// TLS enables HTTPS on Port. The server speaks plain HTTP when no
+	// certificate is configured.
+	TLS TLS `mapstructure:"tls"`
+
 	// RateLimiter configures request rate limiting to protect the server.
 	RateLimiter RateLimiter `mapstructure:"rate_limiter" validate:"required"`
 
 	BuildInfo Build // BuildInfo holds build metadata injected via ldflags for version tracking.
 
-	// Domain is the base domain used for generating short URLs (e.g., "short.link").
+	// Domain is the base URL of the default short domain (e.g., "https://short.link").
+	// Further domains, each with their own codes, are managed through the API.
 	Domain string `mapstructure:"domain" validate:"required"`
 
 	// DBName is the database name to connect to (e.g., "urlshortener").
 	DBName string `mapstructure:"db_name" validate:"required"`
+
+	// AdminToken is the bearer token required by management endpoints such as
+	// link updates. Management endpoints are disabled when it is empty.
+	AdminToken string `mapstructure:"admin_token"`
+
+	// CookieSecret is the key used to sign cookies issued by the server
+	// (e.g., unlocked password-protected links). A random key is generated
+	// at startup when it is empty, which invalidates cookies on restart.
+	CookieSecret string `mapstructure:"cookie_secret"`
+
+	// TrustedProxies lists the CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header is trusted when determining the client IP.
+	TrustedProxies []string `mapstructure:"trusted_proxies" validate:"dive,cidr"`
+
+	// CountryHeader names a request header carrying the visitor's ISO country
+	// code set by a CDN or proxy (e.g., "CF-IPCountry"), used by routing rules.
+	// Without it, the country is looked up in the GeoIP databases.
+	CountryHeader string `mapstructure:"country_header"`
+
+	// AppLinks configures the app association files served under
+	// /.well-known so mobile apps can verify ownership of the short domain.
+	AppLinks AppLinks `mapstructure:"app_links"`
+
+	// BlockedDomains lists destination domains, including their subdomains,
+	// that link previews report as unsafe.
+	BlockedDomains []string `mapstructure:"blocked_domains"`
+
+	// Clicks configures how click events are recorded.
+	Clicks Clicks `mapstructure:"clicks"`
+
+	// Retention configures how long click data is kept.
+	Retention Retention `mapstructure:"retention"`
+
+	// GeoIP configures the local databases clicks are located with.
+	GeoIP GeoIP `mapstructure:"geoip"`
+
+	// DomainVerification configures how ownership of custom domains added
+	// by workspaces is checked.
+	DomainVerification DomainVerification `mapstructure:"domain_verification"`
+}
+
+// Clicks configures the click recorder. Clicks are queued in memory and
+// written in batches; queued clicks are written on graceful shutdown.
+type Clicks struct {
+	// BufferSize is how many clicks may be queued (default 10000).
+	BufferSize int `mapstructure:"buffer_size" validate:"min=0"`
+	// BatchSize is how many clicks are written per transaction (default 500).
+	BatchSize int `mapstructure:"batch_size" validate:"min=0"`
+	// FlushInterval is the longest time a click stays queued (default 1s).
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// Overflow decides what happens when the buffer is full: "drop" the
+	// click (default) or "block" the redirect until there is room.
+	Overflow string `mapstructure:"overflow" validate:"omitempty,oneof=drop block"`
+	// HashIPs stores a hash of the client IP keyed with the salt of the
+	// day, telling clicks from one address apart within a UTC day. Client
+	// IPs are never stored themselves.
+	HashIPs bool `mapstructure:"hash_ips"`
+	// BotRules is a file of rules classifying further user agents as bots,
+	// one "name regexp" pair per line. It is reloaded when it changes.
+	BotRules string `mapstructure:"bot_rules" validate:"omitempty,file"`
+}
+
+// Retention configures the tiers click data ages through. Raw clicks and
+// their minute and hourly rollups are kept for RawDays, then only daily
+// rollups for DailyMonths, then monthly rollups forever. Zero keeps a tier
+// forever.
+type Retention struct {
+	// RawDays is how many days raw clicks are kept.
+	RawDays int `mapstructure:"raw_days" validate:"min=0"`
+	// DailyMonths is how many months daily rollups are kept.
+	DailyMonths int `mapstructure:"daily_months" validate:"min=0"`
+	// BatchSize is how many rows are compacted per transaction (default 500).
+	BatchSize int `mapstructure:"batch_size" validate:"min=0"`
+	// Interval is how often expired data is compacted (default 1h).
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// GeoIP names MaxMind DB files (GeoLite2, DB-IP Lite or compatible) used
+// to locate clicks. They are reloaded when replaced. Clicks are reported
+// with an unknown location when none is configured.
+type GeoIP struct {
+	// Database is a City or Country database, e.g. "GeoLite2-City.mmdb".
+	Database string `mapstructure:"database" validate:"omitempty,file"`
+	// ASNDatabase is an ASN database, e.g. "GeoLite2-ASN.mmdb".
+	ASNDatabase string `mapstructure:"asn_database" validate:"omitempty,file"`
+}
+
+// DomainVerification configures ownership checks of custom domains.
+type DomainVerification struct {
+	// Resolver is the DNS server queried for TXT records (e.g., "1.1.1.1:53").
+	// The system resolver is used when it is empty.
+	Resolver string `mapstructure:"resolver" validate:"omitempty,hostname_port"`
+	// Interval is how often verified domains are checked again (default 24h).
+	Interval time.Duration `mapstructure:"interval"`
+	// GracePeriod is how long a verified domain keeps serving links while
+	// its checks fail (default 72h).
+	GracePeriod time.Duration `mapstructure:"grace_period"`
+}
+
+// TLS configures native TLS termination. Certificates are reloaded when
+// their files change or the process receives SIGHUP.
+type TLS struct {
+	// CertFile and KeyFile are the PEM files of the default certificate.
+	CertFile string `mapstructure:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile  string `mapstructure:"key_file" validate:"required_with=CertFile"`
+
+	// Certificates are additional certificates, e.g. for custom short
+	// domains, selected by SNI from the names they are valid for.
+	Certificates []TLSCertificate `mapstructure:"certificates" validate:"dive"`
+
+	// MinVersion is the lowest accepted protocol version, "1.2" or "1.3"
+	// (default "1.2").
+	MinVersion string `mapstructure:"min_version" validate:"omitempty,oneof=1.2 1.3"`
+
+	// CipherSuites lists the TLS 1.2 cipher suites to accept by their IANA
+	// names (e.g., "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"). Go's secure
+	// defaults are used when it is empty. TLS 1.3 suites are not configurable.
+	CipherSuites []string `mapstructure:"cipher_suites"`
+
+	// RedirectPort is the port of a plain HTTP listener redirecting every
+	// request to HTTPS (e.g., 80). It is disabled when 0.
+	RedirectPort int `mapstructure:"redirect_port"`
+
+	// HSTS sets the Strict-Transport-Security header on HTTPS responses.
+	HSTS HSTS `mapstructure:"hsts"`
+}
+
+// Enabled reports whether TLS is configured.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// TLSCertificate is a certificate and key pair of PEM files.
+type TLSCertificate struct {
+	CertFile string `mapstructure:"cert_file" validate:"required"`
+	KeyFile  string `mapstructure:"key_file" validate:"required"`
+}
+
+// HSTS configures the Strict-Transport-Security header. It is not sent
+// when MaxAge is 0.
+type HSTS struct {
+	MaxAge            time.Duration `mapstructure:"max_age"` // e.g. 8760h
+	IncludeSubdomains bool          `mapstructure:"include_subdomains"`
+	Preload           bool          `mapstructure:"preload"`
+}
+
+// AppLinks describes the mobile apps allowed to open short links directly.
+type AppLinks struct {
+	Apple   AppleAppLinks    `mapstructure:"apple"`
+	Android []AndroidAppLink `mapstructure:"android" validate:"dive"`
+}
+
+// AppleAppLinks is rendered as /.well-known/apple-app-site-association.
+type AppleAppLinks struct {
+	AppIDs []string `mapstructure:"app_ids"` // "<TeamID>.<BundleID>" identifiers
+	Paths  []string `mapstructure:"paths"`   // Path patterns opened in the app (default "/*")
+}
+
+// AndroidAppLink is one statement of /.well-known/assetlinks.json.
+type AndroidAppLink struct {
+	PackageName            string   `mapstructure:"package_name" validate:"required"`
+	SHA256CertFingerprints []string `mapstructure:"sha256_cert_fingerprints" validate:"required,min=1"`
 }
 
 // RateLimiter defines the rate limiting configuration.
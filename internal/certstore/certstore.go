@@ -0,0 +1,95 @@
+// Package certstore keeps TLS certificates loaded from disk, selects them by
+// SNI and reloads them when their files change, so renewed certificates are
+// picked up without a restart.
+package certstore
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/joybiswas007/linkshort/internal/filewatch"
+)
+
+// Pair names the PEM files of a certificate and its private key.
+type Pair struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Store holds the loaded certificates. The first one is served to clients
+// that send no server name or one no certificate is valid for.
+type Store struct {
+	pairs []Pair
+
+	mu    sync.RWMutex
+	certs []*tls.Certificate
+}
+
+// New loads the certificates of pairs.
+func New(pairs []Pair) (*Store, error) {
+	if len(pairs) == 0 {
+		return nil, errors.New("no certificates configured")
+	}
+
+	s := &Store{pairs: pairs}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload loads all certificates again. When any of them fails to load the
+// previous certificates stay in use.
+func (s *Store) Reload() error {
+	certs := make([]*tls.Certificate, 0, len(s.pairs))
+	for _, p := range s.pairs {
+		cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+		if err != nil {
+			return fmt.Errorf("load certificate %s: %w", p.CertFile, err)
+		}
+		certs = append(certs, &cert)
+	}
+
+	s.mu.Lock()
+	s.certs = certs
+	s.mu.Unlock()
+	return nil
+}
+
+// GetCertificate returns the certificate valid for the server name of the
+// handshake. It is meant for tls.Config.GetCertificate.
+func (s *Store) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if hello.ServerName != "" {
+		for _, cert := range s.certs {
+			if cert.Leaf != nil && cert.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return cert, nil
+			}
+		}
+	}
+	return s.certs[0], nil
+}
+
+// Watch reloads the certificates when their files change or the process
+// receives SIGHUP, until ctx is done.
+func (s *Store) Watch(ctx context.Context) error {
+	files := make([]string, 0, 2*len(s.pairs))
+	for _, p := range s.pairs {
+		files = append(files, p.CertFile, p.KeyFile)
+	}
+	return filewatch.Watch(ctx, files, s.reload)
+}
+
+func (s *Store) reload(reason string) {
+	if err := s.Reload(); err != nil {
+		log.Printf("reload certificates on %s: %v", reason, err)
+		return
+	}
+	log.Printf("reloaded certificates on %s", reason)
+}
@@ -0,0 +1,133 @@
+package certstore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for host to dir and returns
+// its files.
+func writeCert(t *testing.T, dir, host string) Pair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := Pair{CertFile: filepath.Join(dir, host+".crt"), KeyFile: filepath.Join(dir, host+".key")}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(p.CertFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p.KeyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func serverCert(t *testing.T, s *Store, name string) *tls.Certificate {
+	t.Helper()
+	cert, err := s.GetCertificate(&tls.ClientHelloInfo{ServerName: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestGetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New([]Pair{writeCert(t, dir, "short.test"), writeCert(t, dir, "go.brand.test")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		serverName string
+		want       string
+	}{
+		{"short.test", "short.test"},
+		{"go.brand.test", "go.brand.test"},
+		{"unknown.test", "short.test"},
+		{"", "short.test"},
+	}
+
+	for _, tt := range tests {
+		if got := serverCert(t, s, tt.serverName).Leaf.Subject.CommonName; got != tt.want {
+			t.Errorf("GetCertificate(%q) = %s, want %s", tt.serverName, got, tt.want)
+		}
+	}
+}
+
+func TestReloadKeepsCertificatesOnError(t *testing.T) {
+	dir := t.TempDir()
+	p := writeCert(t, dir, "short.test")
+	s, err := New([]Pair{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := serverCert(t, s, "short.test")
+
+	if err := os.WriteFile(p.CertFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err == nil {
+		t.Fatal("Reload() of a broken certificate succeeded")
+	}
+	if serverCert(t, s, "short.test") != before {
+		t.Error("broken reload replaced the certificate")
+	}
+}
+
+func TestWatchReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New([]Pair{writeCert(t, dir, "short.test")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := serverCert(t, s, "short.test").Leaf.SerialNumber
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	writeCert(t, dir, "short.test")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if serverCert(t, s, "short.test").Leaf.SerialNumber.Cmp(before) != 0 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Error("certificate was not reloaded after its files changed")
+}
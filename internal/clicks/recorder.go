@@ -0,0 +1,175 @@
+// Package clicks records click events off the redirect path. Events are
+// queued in a bounded buffer and written in batches by a background writer,
+// so redirects never wait on the database.
+package clicks
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// Overflow policies, deciding what Record does when the buffer is full.
+const (
+	Drop  = "drop"  // discard the event and count it in clicks_dropped
+	Block = "block" // wait for the writer to make room
+)
+
+var (
+	recorded = expvar.NewInt("clicks_recorded")
+	dropped  = expvar.NewInt("clicks_dropped")
+	failed   = expvar.NewInt("clicks_failed")
+)
+
+// Store persists batches of clicks.
+type Store interface {
+	InsertBatch(clicks []*database.Click) error
+}
+
+// Options configure a Recorder. Zero values select the defaults.
+type Options struct {
+	BufferSize    int           // events queued before the overflow policy applies (default 10000)
+	BatchSize     int           // events written per transaction (default 500)
+	FlushInterval time.Duration // longest time an event waits to be written (default 1s)
+	Overflow      string        // Drop (default) or Block
+
+	// Enrich, when set, derives further fields of a click before it is
+	// written, off the request path.
+	Enrich func(c *database.Click)
+
+	// Written, when set, is called with every batch once it is stored. It
+	// runs on the writer and must not retain the slice.
+	Written func(clicks []*database.Click)
+}
+
+// Recorder queues clicks and writes them to a Store in batches.
+type Recorder struct {
+	store     Store
+	enrich    func(c *database.Click)
+	written   func(clicks []*database.Click)
+	events    chan *database.Click
+	block     bool
+	batchSize int
+	interval  time.Duration
+
+	mu     sync.RWMutex // guards closed against concurrent sends
+	closed bool
+	done   chan struct{}
+}
+
+// New starts a recorder writing to store.
+func New(store Store, opts Options) *Recorder {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 10000
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+
+	r := &Recorder{
+		store:     store,
+		enrich:    opts.Enrich,
+		written:   opts.Written,
+		events:    make(chan *database.Click, opts.BufferSize),
+		block:     opts.Overflow == Block,
+		batchSize: opts.BatchSize,
+		interval:  opts.FlushInterval,
+		done:      make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Record queues a click. It reports whether the click was queued: it is
+// dropped when the recorder is closed, or when the buffer is full and the
+// overflow policy is Drop.
+func (r *Recorder) Record(c *database.Click) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		dropped.Add(1)
+		return false
+	}
+
+	if r.block {
+		r.events <- c
+		return true
+	}
+
+	select {
+	case r.events <- c:
+		return true
+	default:
+		dropped.Add(1)
+		return false
+	}
+}
+
+// Close stops accepting clicks and waits until the queued ones are written
+// or ctx is done.
+func (r *Recorder) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.events)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make([]*database.Click, 0, r.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.store.InsertBatch(batch); err != nil {
+			log.Printf("record %d clicks: %v", len(batch), err)
+			failed.Add(int64(len(batch)))
+		} else {
+			recorded.Add(int64(len(batch)))
+			if r.written != nil {
+				r.written(batch)
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case c, ok := <-r.events:
+			if !ok {
+				flush()
+				return
+			}
+			if r.enrich != nil {
+				r.enrich(c)
+			}
+			batch = append(batch, c)
+			if len(batch) >= r.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
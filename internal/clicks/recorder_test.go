@@ -0,0 +1,145 @@
+package clicks
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	batches [][]*database.Click
+	release chan struct{} // when set, InsertBatch waits for it
+}
+
+func (s *memStore) InsertBatch(clicks []*database.Click) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]*database.Click(nil), clicks...))
+	return nil
+}
+
+func (s *memStore) count() (clicks, batches int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range s.batches {
+		clicks += len(b)
+	}
+	return clicks, len(s.batches)
+}
+
+func TestCloseFlushesBatches(t *testing.T) {
+	store := &memStore{}
+	r := New(store, Options{BatchSize: 10, FlushInterval: time.Hour})
+
+	for i := range 25 {
+		if !r.Record(&database.Click{LinkID: i}) {
+			t.Fatalf("Record(%d) was dropped", i)
+		}
+	}
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	clicks, batches := store.count()
+	if clicks != 25 || batches != 3 {
+		t.Errorf("wrote %d clicks in %d batches, want 25 in 3", clicks, batches)
+	}
+	if r.Record(&database.Click{}) {
+		t.Error("Record() after Close() was queued")
+	}
+}
+
+func TestWritten(t *testing.T) {
+	var written []int
+	r := New(&memStore{}, Options{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Written: func(clicks []*database.Click) {
+			for _, c := range clicks {
+				written = append(written, c.LinkID)
+			}
+		},
+	})
+
+	for i := range 3 {
+		r.Record(&database.Click{LinkID: i})
+	}
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(written) != 3 || written[0] != 0 || written[2] != 2 {
+		t.Errorf("Written got %v, want [0 1 2]", written)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	store := &memStore{}
+	r := New(store, Options{FlushInterval: 10 * time.Millisecond})
+	defer r.Close(context.Background())
+
+	r.Record(&database.Click{})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if clicks, _ := store.count(); clicks == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("click was not written after the flush interval")
+}
+
+func TestOverflow(t *testing.T) {
+	tests := []struct {
+		overflow string
+		wantSent bool
+	}{
+		{Drop, false},
+		{Block, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.overflow, func(t *testing.T) {
+			// The writer takes the first click and stalls, the second one
+			// fills the buffer.
+			store := &memStore{release: make(chan struct{})}
+			r := New(store, Options{BufferSize: 1, BatchSize: 1, Overflow: tt.overflow})
+			r.Record(&database.Click{})
+			time.Sleep(20 * time.Millisecond)
+			r.Record(&database.Click{})
+
+			sent := make(chan bool, 1)
+			go func() { sent <- r.Record(&database.Click{}) }()
+
+			select {
+			case ok := <-sent:
+				if tt.overflow == Block {
+					t.Fatalf("Record() returned %v on a full buffer, want it to block", ok)
+				}
+				if ok {
+					t.Error("Record() on a full buffer was queued")
+				}
+			case <-time.After(50 * time.Millisecond):
+				if tt.overflow == Drop {
+					t.Fatal("Record() blocked on a full buffer")
+				}
+			}
+
+			close(store.release)
+			if err := r.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if tt.overflow == Block && !<-sent {
+				t.Error("blocked Record() was dropped")
+			}
+		})
+	}
+}
@@ -0,0 +1,974 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/hll"
+)
+
+// Dimensions clicks are broken down by in statistics.
+const (
+	DimensionReferrer = "referrer"
+	DimensionCountry  = "country"
+	DimensionBrowser  = "browser"
+	DimensionOS       = "os"
+	DimensionDevice   = "device"
+	DimensionRegion   = "region"
+	DimensionCity     = "city"
+	DimensionASN      = "asn"
+	DimensionBot      = "bot"
+)
+
+// Dimensions lists every dimension clicks are broken down by.
+var Dimensions = []string{
+	DimensionReferrer, DimensionCountry, DimensionRegion, DimensionCity, DimensionASN,
+	DimensionBrowser, DimensionOS, DimensionDevice, DimensionBot,
+}
+
+// Click is a redirect served for a link.
+type Click struct {
+	ID     int `json:"id"`
+	LinkID int `json:"-"`
+	// WorkspaceID is the workspace of the link. It is not stored.
+	WorkspaceID int       `json:"-"`
+	Code        string    `json:"code"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	Referrer    string    `json:"referrer,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	// ClientIP is the address of the client. It is only used to derive the
+	// fields below and is never stored.
+	ClientIP string `json:"-"`
+	// IP is a hash of the client IP that changes daily, set when IPs are
+	// hashed.
+	IP             string `json:"ip,omitempty"`
+	AcceptLanguage string `json:"accept_language,omitempty"`
+
+	// Derived from the fields above when the click is recorded. Empty
+	// values are unknown.
+	ReferrerHost string `json:"referrer_host,omitempty"`
+	Country      string `json:"country,omitempty"`
+	Region       string `json:"region,omitempty"`
+	City         string `json:"city,omitempty"`
+	ASN          string `json:"asn,omitempty"`
+	Browser      string `json:"browser,omitempty"`
+	OS           string `json:"os,omitempty"`
+	Device       string `json:"device,omitempty"`
+
+	// IsBot is set for clicks from crawlers, link previews and monitors,
+	// named by Bot. They are counted apart from human clicks.
+	IsBot bool   `json:"is_bot"`
+	Bot   string `json:"bot,omitempty"`
+
+	// ClickID is the click ID appended to the destination of links with
+	// click IDs enabled, which conversions are attributed to.
+	ClickID string `json:"click_id,omitempty"`
+
+	// ExperimentID and Variant name the variant of the running experiment
+	// the visitor was sent to.
+	ExperimentID string `json:"experiment_id,omitempty"`
+	Variant      string `json:"variant,omitempty"`
+
+	// Visitor identifies the client across the clicks of a UTC day; the
+	// click is Unique when it is the first one of its visitor on the link
+	// that day. Bot clicks are never unique.
+	Visitor string `json:"-"`
+	Unique  bool   `json:"unique"`
+}
+
+// Dimension returns the value of the click for a dimension.
+func (c *Click) Dimension(name string) string {
+	switch name {
+	case DimensionReferrer:
+		return c.ReferrerHost
+	case DimensionCountry:
+		return c.Country
+	case DimensionRegion:
+		return c.Region
+	case DimensionCity:
+		return c.City
+	case DimensionASN:
+		return c.ASN
+	case DimensionBrowser:
+		return c.Browser
+	case DimensionOS:
+		return c.OS
+	case DimensionDevice:
+		return c.Device
+	case DimensionBot:
+		return c.Bot
+	}
+	return ""
+}
+
+// StatPoint is the number of clicks in the bucket starting at Time. Bots
+// counts bot clicks when they are included in Clicks.
+type StatPoint struct {
+	Time    time.Time `json:"time"`
+	Clicks  int       `json:"clicks"`
+	Uniques int       `json:"uniques"`
+	Bots    int       `json:"bots,omitempty"`
+}
+
+// StatCount is the number of clicks with a dimension value. Bots counts bot
+// clicks when they are included in Clicks.
+type StatCount struct {
+	Value   string `json:"value"`
+	Clicks  int    `json:"clicks"`
+	Uniques int    `json:"uniques"`
+	Bots    int    `json:"bots,omitempty"`
+}
+
+// ClickStore persists clicks and the statistics rolled up from them.
+type ClickStore interface {
+	// InsertBatch stores clicks, marks the first click of every human
+	// visitor per link and UTC day as unique and adds them to the
+	// statistics. Only human clicks are added to the click counters of
+	// their links and experiment variants.
+	InsertBatch(clicks []*Click) error
+	// Salt returns the secret salt visitors are hashed with on the UTC day
+	// of t, creating it on first use. Salts and visitors of the days
+	// before yesterday are deleted.
+	Salt(t time.Time) ([]byte, error)
+
+	// Series returns the clicks of a link in [from, to) per time.Minute or
+	// time.Hour, with coarser points for compacted days and months. Only
+	// points with clicks are returned, in order. Bot clicks are added to
+	// the human ones when bots is set.
+	Series(linkID int, resolution time.Duration, from, to time.Time, bots bool) ([]StatPoint, error)
+	// Top returns the n most frequent values of a dimension among the
+	// clicks of a link in the UTC days overlapping [from, to). Bot clicks
+	// are added to the human ones when bots is set.
+	Top(linkID int, dimension string, from, to time.Time, n int, bots bool) ([]StatCount, error)
+	// Uniques estimates the distinct visitors of a link in the UTC days
+	// overlapping [from, to), counting returning visitors once per day.
+	Uniques(linkID int, from, to time.Time) (int, error)
+	// VariantClicks returns the number of human clicks of each variant of
+	// an experiment.
+	VariantClicks(linkID int, experimentID string) (map[string]int, error)
+
+	// LastID returns the ID of the last click recorded, 0 when there is
+	// none. No click with a smaller ID is stored later.
+	LastID() (int, error)
+	// List returns up to limit clicks matching filter in the order of
+	// their IDs.
+	List(filter ClickFilter, limit int) ([]*Click, error)
+
+	// DeleteClicks deletes up to limit clicks recorded before t, oldest
+	// first, and returns how many were deleted. Their statistics stay.
+	// The last click is always kept, so IDs keep increasing.
+	DeleteClicks(before time.Time, limit int) (int, error)
+	// CompactHours moves up to limit hourly rollups starting before t into
+	// the daily ones, dropping their minute rollups, and returns how many
+	// were moved.
+	CompactHours(before time.Time, limit int) (int, error)
+	// CompactDays moves up to limit daily rollups of each kind starting
+	// before t into the monthly ones and returns how many were moved.
+	CompactDays(before time.Time, limit int) (int, error)
+}
+
+// ClickModel stores clicks in SQLite.
+type ClickModel struct {
+	DB *sql.DB
+}
+
+var _ ClickStore = (*ClickModel)(nil)
+
+type seriesKey struct {
+	linkID int
+	bucket int64
+}
+
+type variantKey struct {
+	linkID       int
+	experimentID string
+	variant      string
+}
+
+type dimensionKey struct {
+	linkID    int
+	dimension string
+	day       int64
+	value     string
+}
+
+// InsertBatch stores clicks in a single transaction, marks the first click
+// of every human visitor per day as unique and adds them to the statistics
+// rollups and visitor sketches. Only human clicks are added to the click
+// counters of their links and experiment variants.
+func (m ClickModel) InsertBatch(clicks []*Click) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	visitor, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO link_visitors (link_id, day, visitor) VALUES ($1, $2, $3)`)
+	if err != nil {
+		return err
+	}
+	defer visitor.Close()
+
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO clicks (link_id, code, clicked_at, referrer, user_agent, ip, accept_language,
+			referrer_host, country, region, city, asn, browser, os, device, is_bot, bot, visitor, is_unique, click_id,
+			experiment_id, variant)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+	`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	counts := make(map[int]int)
+	variants := make(map[variantKey]int)
+	minutes := make(map[seriesKey]*StatPoint)
+	hours := make(map[seriesKey]*StatPoint)
+	dimensions := make(map[dimensionKey]*StatCount)
+	sketches := make(map[seriesKey][]string)
+
+	add := func(clicks, uniques, bots *int, c *Click) {
+		switch {
+		case c.IsBot:
+			*bots++
+		case c.Unique:
+			*uniques++
+			fallthrough
+		default:
+			*clicks++
+		}
+	}
+
+	for _, c := range clicks {
+		unix := c.ClickedAt.Unix()
+		day := unix - unix%86400
+
+		if c.Visitor != "" && !c.IsBot {
+			result, err := visitor.ExecContext(ctx, c.LinkID, day, c.Visitor)
+			if err != nil {
+				return err
+			}
+			rows, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			c.Unique = rows == 1
+			if c.Unique {
+				key := seriesKey{c.LinkID, day}
+				sketches[key] = append(sketches[key], c.Visitor)
+			}
+		}
+
+		_, err := insert.ExecContext(ctx, c.LinkID, c.Code, c.ClickedAt.UTC(), c.Referrer, c.UserAgent, c.IP,
+			c.AcceptLanguage, c.ReferrerHost, c.Country, c.Region, c.City, c.ASN, c.Browser, c.OS, c.Device,
+			c.IsBot, c.Bot, c.Visitor, c.Unique, c.ClickID, c.ExperimentID, c.Variant)
+		if err != nil {
+			return err
+		}
+
+		if !c.IsBot {
+			counts[c.LinkID]++
+			if c.Variant != "" {
+				variants[variantKey{c.LinkID, c.ExperimentID, c.Variant}]++
+			}
+		}
+
+		for _, rollup := range []struct {
+			points map[seriesKey]*StatPoint
+			size   int64
+		}{{minutes, 60}, {hours, 3600}} {
+			key := seriesKey{c.LinkID, unix - unix%rollup.size}
+			if rollup.points[key] == nil {
+				rollup.points[key] = &StatPoint{}
+			}
+			p := rollup.points[key]
+			add(&p.Clicks, &p.Uniques, &p.Bots, c)
+		}
+
+		for _, dim := range Dimensions {
+			// Human clicks have no bot to break down.
+			if dim == DimensionBot && !c.IsBot {
+				continue
+			}
+			key := dimensionKey{c.LinkID, dim, day, c.Dimension(dim)}
+			if dimensions[key] == nil {
+				dimensions[key] = &StatCount{}
+			}
+			n := dimensions[key]
+			add(&n.Clicks, &n.Uniques, &n.Bots, c)
+		}
+	}
+
+	for linkID, n := range counts {
+		_, err := tx.ExecContext(ctx, `UPDATE links SET clicks = clicks + $1 WHERE id = $2`, n, linkID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for key, n := range variants {
+		query := `
+			INSERT INTO experiment_clicks (link_id, experiment_id, variant, clicks)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (link_id, experiment_id, variant) DO UPDATE SET clicks = clicks + excluded.clicks
+		`
+		if _, err := tx.ExecContext(ctx, query, key.linkID, key.experimentID, key.variant, n); err != nil {
+			return err
+		}
+	}
+
+	for rollup, points := range map[string]map[seriesKey]*StatPoint{"minute": minutes, "hour": hours} {
+		for key, p := range points {
+			if err := addToSeries(ctx, tx, rollup, key, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, c := range dimensions {
+		if err := addToDimension(ctx, tx, "day", key, c); err != nil {
+			return err
+		}
+	}
+
+	for key, visitors := range sketches {
+		if err := addToSketch(ctx, tx, key.linkID, key.bucket, visitors); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// addToSeries adds the counts of p to the minute, hour, day or month rollup
+// of a link.
+func addToSeries(ctx context.Context, tx *sql.Tx, rollup string, key seriesKey, p *StatPoint) error {
+	query := fmt.Sprintf(`
+		INSERT INTO click_stats_%[1]s (link_id, %[1]s, clicks, uniques, bots)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (link_id, %[1]s) DO UPDATE
+		SET clicks = clicks + excluded.clicks, uniques = uniques + excluded.uniques,
+			bots = bots + excluded.bots
+	`, rollup)
+	_, err := tx.ExecContext(ctx, query, key.linkID, key.bucket, p.Clicks, p.Uniques, p.Bots)
+	return err
+}
+
+// addToDimension adds the counts of c to the daily or monthly dimension
+// rollup of a link; key.day holds the start of the day or month.
+func addToDimension(ctx context.Context, tx *sql.Tx, rollup string, key dimensionKey, c *StatCount) error {
+	table := "click_stats_dimensions"
+	if rollup == "month" {
+		table += "_month"
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (link_id, dimension, %[2]s, value, clicks, uniques, bots)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (link_id, dimension, %[2]s, value) DO UPDATE
+		SET clicks = clicks + excluded.clicks, uniques = uniques + excluded.uniques,
+			bots = bots + excluded.bots
+	`, table, rollup)
+	_, err := tx.ExecContext(ctx, query, key.linkID, key.dimension, key.day, key.value, c.Clicks, c.Uniques, c.Bots)
+	return err
+}
+
+// loadSketch returns the daily or monthly visitor sketch of a link, empty
+// when there is none yet.
+func loadSketch(ctx context.Context, tx *sql.Tx, rollup string, key seriesKey) (*hll.Sketch, error) {
+	sketch, err := hll.New(hll.Precision)
+	if err != nil {
+		return nil, err
+	}
+
+	table := "click_sketches"
+	if rollup == "month" {
+		table += "_month"
+	}
+
+	var b []byte
+	query := fmt.Sprintf(`SELECT sketch FROM %s WHERE link_id = $1 AND %s = $2`, table, rollup)
+	err = tx.QueryRowContext(ctx, query, key.linkID, key.bucket).Scan(&b)
+	switch {
+	case err == nil:
+		if err := sketch.UnmarshalBinary(b); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	return sketch, nil
+}
+
+// storeSketch saves the daily or monthly visitor sketch of a link.
+func storeSketch(ctx context.Context, tx *sql.Tx, rollup string, key seriesKey, sketch *hll.Sketch) error {
+	b, err := sketch.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	table := "click_sketches"
+	if rollup == "month" {
+		table += "_month"
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (link_id, %[2]s, sketch) VALUES ($1, $2, $3)
+		ON CONFLICT (link_id, %[2]s) DO UPDATE SET sketch = excluded.sketch
+	`, table, rollup)
+	_, err = tx.ExecContext(ctx, query, key.linkID, key.bucket, b)
+	return err
+}
+
+// addToSketch adds visitors to the sketch of a link for the day starting at
+// the unix time day.
+func addToSketch(ctx context.Context, tx *sql.Tx, linkID int, day int64, visitors []string) error {
+	key := seriesKey{linkID, day}
+	sketch, err := loadSketch(ctx, tx, "day", key)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range visitors {
+		sketch.Add([]byte(v))
+	}
+
+	return storeSketch(ctx, tx, "day", key, sketch)
+}
+
+// Uniques estimates the distinct visitors of a link in the UTC days
+// overlapping [from, to) by merging the sketches of the days, and of the
+// months overlapping it once their days are compacted. Visitors are only
+// recognized within a day, so those returning on several days are counted
+// once per day.
+func (m ClickModel) Uniques(linkID int, from, to time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT sketch FROM click_sketches WHERE link_id = $1 AND day > $2 AND day < $3
+		UNION ALL
+		SELECT sketch FROM click_sketches_month WHERE link_id = $1 AND month >= $4 AND month < $3
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, linkID, from.Unix()-86400, to.Unix(), startOfMonth(from.Unix()))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	merged, err := hll.New(hll.Precision)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return 0, err
+		}
+		var day hll.Sketch
+		if err := day.UnmarshalBinary(b); err != nil {
+			return 0, err
+		}
+		if err := merged.Merge(&day); err != nil {
+			return 0, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	return int(merged.Estimate()), nil
+}
+
+// Salt returns the secret salt visitors are hashed with on the UTC day of t,
+// creating it on first use. Salts and visitors of the days before yesterday
+// are deleted then, so visitor hashes can neither be traced back to clients
+// nor linked across days.
+func (m ClickModel) Salt(t time.Time) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	unix := t.Unix()
+	day := unix - unix%86400
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	salt := make([]byte, 32)
+	rand.Read(salt)
+
+	result, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO visitor_salts (day, salt) VALUES ($1, $2)`, day, salt)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rows == 1 {
+		for _, table := range []string{"visitor_salts", "link_visitors"} {
+			query := fmt.Sprintf(`DELETE FROM %s WHERE day < $1`, table)
+			if _, err := tx.ExecContext(ctx, query, day-86400); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT salt FROM visitor_salts WHERE day = $1`, day).Scan(&salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return salt, tx.Commit()
+}
+
+// Series returns the clicks of a link in [from, to) from the rollup with the
+// given resolution, time.Minute or time.Hour. Days and months compacted into
+// coarser rollups are returned as one point each, at the start of the day
+// or month or at from when that is later, so they may share the time of a
+// finer point. Only points with clicks are returned, in order. Bot clicks
+// are added to the human ones when bots is set, and left out otherwise.
+func (m ClickModel) Series(linkID int, resolution time.Duration, from, to time.Time, bots bool) ([]StatPoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var table string
+	switch resolution {
+	case time.Minute:
+		table = "minute"
+	case time.Hour:
+		table = "hour"
+	default:
+		return nil, fmt.Errorf("no rollup with resolution %s", resolution)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT bucket, clicks, uniques, CASE WHEN $1 THEN bots ELSE 0 END
+		FROM (
+			SELECT %[1]s AS bucket, clicks, uniques, bots FROM click_stats_%[1]s
+			WHERE link_id = $2 AND %[1]s >= $3 AND %[1]s < $4
+			UNION ALL
+			SELECT day, clicks, uniques, bots FROM click_stats_day
+			WHERE link_id = $2 AND day > $5 AND day < $4
+			UNION ALL
+			SELECT month, clicks, uniques, bots FROM click_stats_month
+			WHERE link_id = $2 AND month >= $6 AND month < $4
+		)
+		ORDER BY bucket
+	`, table)
+
+	unix := from.Unix()
+	rows, err := m.DB.QueryContext(ctx, query, bots, linkID, unix, to.Unix(), unix-86400, startOfMonth(unix))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []StatPoint
+	for rows.Next() {
+		var p StatPoint
+		var unix int64
+		if err := rows.Scan(&unix, &p.Clicks, &p.Uniques, &p.Bots); err != nil {
+			return nil, err
+		}
+		p.Time = time.Unix(unix, 0).UTC()
+		if p.Time.Before(from) {
+			p.Time = from.UTC()
+		}
+		p.Clicks += p.Bots
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// Top returns the n most frequent values of a dimension among the clicks of
+// a link in the UTC days overlapping [from, to), or the UTC months
+// overlapping it once their days are compacted. Bot clicks are added to the
+// human ones when bots is set, and left out otherwise.
+func (m ClickModel) Top(linkID int, dimension string, from, to time.Time, n int, bots bool) ([]StatCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT value, SUM(clicks) + SUM(bots), SUM(uniques), SUM(bots)
+		FROM (
+			SELECT value, clicks, uniques, CASE WHEN $1 THEN bots ELSE 0 END AS bots
+			FROM click_stats_dimensions
+			WHERE link_id = $2 AND dimension = $3 AND day > $4 AND day < $5
+			UNION ALL
+			SELECT value, clicks, uniques, CASE WHEN $1 THEN bots ELSE 0 END
+			FROM click_stats_dimensions_month
+			WHERE link_id = $2 AND dimension = $3 AND month >= $6 AND month < $5
+		)
+		GROUP BY value
+		HAVING SUM(clicks) + SUM(bots) > 0
+		ORDER BY SUM(clicks) + SUM(bots) DESC, value
+		LIMIT $7
+	`
+
+	unix := from.Unix()
+	rows, err := m.DB.QueryContext(ctx, query, bots, linkID, dimension, unix-86400, to.Unix(), startOfMonth(unix), n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []StatCount{}
+	for rows.Next() {
+		var c StatCount
+		if err := rows.Scan(&c.Value, &c.Clicks, &c.Uniques, &c.Bots); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// ClickFilter selects clicks. Zero fields select all clicks.
+type ClickFilter struct {
+	LinkID      int
+	WorkspaceID int
+	From        time.Time // clicked at or after
+	To          time.Time // clicked before
+	AfterID     int       // with a greater ID
+	UntilID     int       // with this ID or a smaller one
+}
+
+// LastID returns the ID of the last click recorded, 0 when there is none.
+// Clicks are written in transactions, so no click with a smaller ID is
+// stored later.
+func (m ClickModel) LastID() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var id int
+	err := m.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM clicks`).Scan(&id)
+	return id, err
+}
+
+// List returns up to limit clicks matching filter in the order of their IDs.
+func (m ClickModel) List(filter ClickFilter, limit int) ([]*Click, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.link_id, l.workspace_id, c.code, c.clicked_at, c.referrer, c.user_agent, c.ip,
+			c.accept_language, c.referrer_host, c.country, c.region, c.city, c.asn, c.browser, c.os,
+			c.device, c.is_bot, c.bot, c.visitor, c.is_unique, c.click_id, c.experiment_id, c.variant
+		FROM clicks c
+		JOIN links l ON l.id = c.link_id
+		WHERE c.id > $1 AND ($2 = 0 OR c.id <= $2)
+			AND ($3 = 0 OR c.link_id = $3)
+			AND ($4 = 0 OR l.workspace_id = $4)
+			AND ($5 IS NULL OR c.clicked_at >= $5)
+			AND ($6 IS NULL OR c.clicked_at < $6)
+		ORDER BY c.id
+		LIMIT $7
+	`
+
+	// Unset bounds are passed as NULL; clicks are stored in UTC.
+	bound := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC()
+	}
+
+	rows, err := m.DB.QueryContext(ctx, query, filter.AfterID, filter.UntilID, filter.LinkID,
+		filter.WorkspaceID, bound(filter.From), bound(filter.To), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clicks := []*Click{}
+	for rows.Next() {
+		var c Click
+		err := rows.Scan(&c.ID, &c.LinkID, &c.WorkspaceID, &c.Code, &c.ClickedAt, &c.Referrer, &c.UserAgent,
+			&c.IP, &c.AcceptLanguage, &c.ReferrerHost, &c.Country, &c.Region, &c.City, &c.ASN, &c.Browser,
+			&c.OS, &c.Device, &c.IsBot, &c.Bot, &c.Visitor, &c.Unique, &c.ClickID, &c.ExperimentID, &c.Variant)
+		if err != nil {
+			return nil, err
+		}
+		clicks = append(clicks, &c)
+	}
+
+	return clicks, rows.Err()
+}
+
+// startOfMonth returns the unix time of the start of the UTC month
+// containing the unix time t.
+func startOfMonth(t int64) int64 {
+	y, m, _ := time.Unix(t, 0).UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+// DeleteClicks deletes up to limit clicks recorded before t, oldest first,
+// and returns how many were deleted. Their counts stay in the rollups and
+// their conversions are kept, but their click IDs expire with them. The
+// last click is always kept, so the IDs of new clicks keep increasing and
+// export cursors stay valid.
+func (m ClickModel) DeleteClicks(before time.Time, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		DELETE FROM clicks WHERE id IN (
+			SELECT id FROM clicks
+			WHERE clicked_at < $1 AND id < (SELECT MAX(id) FROM clicks)
+			ORDER BY clicked_at
+			LIMIT $2
+		)
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// CompactHours moves up to limit hourly rollups starting before t into the
+// daily rollups and deletes the minute rollups of their hours. It returns
+// how many hourly rollups were moved.
+func (m ClickModel) CompactHours(before time.Time, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT link_id, hour, clicks, uniques, bots FROM click_stats_hour
+		WHERE hour < $1
+		ORDER BY hour
+		LIMIT $2
+	`
+	points, err := querySeries(ctx, tx, query, before.Unix(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for key, p := range points {
+		day := seriesKey{key.linkID, key.bucket - key.bucket%86400}
+		if err := addToSeries(ctx, tx, "day", day, p); err != nil {
+			return 0, err
+		}
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM click_stats_hour WHERE link_id = $1 AND hour = $2`, key.linkID, key.bucket)
+		if err != nil {
+			return 0, err
+		}
+		query := `DELETE FROM click_stats_minute WHERE link_id = $1 AND minute >= $2 AND minute < $3`
+		if _, err := tx.ExecContext(ctx, query, key.linkID, key.bucket, key.bucket+3600); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(points), tx.Commit()
+}
+
+// CompactDays moves up to limit daily rollups, dimension rollups and
+// visitor sketches each of the days starting before t into the monthly
+// ones. It returns how many daily rows were moved.
+func (m ClickModel) CompactDays(before time.Time, limit int) (int, error) {
+	var total int
+	for _, compact := range []func(int64, int) (int, error){m.compactDailySeries, m.compactDailyDimensions, m.compactDailySketches} {
+		n, err := compact(before.Unix(), limit)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (m ClickModel) compactDailySeries(before int64, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT link_id, day, clicks, uniques, bots FROM click_stats_day
+		WHERE day < $1
+		ORDER BY day
+		LIMIT $2
+	`
+	points, err := querySeries(ctx, tx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for key, p := range points {
+		month := seriesKey{key.linkID, startOfMonth(key.bucket)}
+		if err := addToSeries(ctx, tx, "month", month, p); err != nil {
+			return 0, err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM click_stats_day WHERE link_id = $1 AND day = $2`, key.linkID, key.bucket)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(points), tx.Commit()
+}
+
+func (m ClickModel) compactDailyDimensions(before int64, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT link_id, dimension, day, value, clicks, uniques, bots FROM click_stats_dimensions
+		WHERE day < $1
+		ORDER BY day
+		LIMIT $2
+	`
+	rows, err := tx.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	counts := make(map[dimensionKey]*StatCount)
+	for rows.Next() {
+		var key dimensionKey
+		var c StatCount
+		if err := rows.Scan(&key.linkID, &key.dimension, &key.day, &key.value, &c.Clicks, &c.Uniques, &c.Bots); err != nil {
+			return 0, err
+		}
+		counts[key] = &c
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	for key, c := range counts {
+		month := key
+		month.day = startOfMonth(key.day)
+		if err := addToDimension(ctx, tx, "month", month, c); err != nil {
+			return 0, err
+		}
+
+		query := `DELETE FROM click_stats_dimensions WHERE link_id = $1 AND dimension = $2 AND day = $3 AND value = $4`
+		if _, err := tx.ExecContext(ctx, query, key.linkID, key.dimension, key.day, key.value); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(counts), tx.Commit()
+}
+
+func (m ClickModel) compactDailySketches(before int64, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT link_id, day, sketch FROM click_sketches WHERE day < $1 ORDER BY day LIMIT $2`
+	rows, err := tx.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var days []seriesKey
+	months := make(map[seriesKey]*hll.Sketch)
+	for rows.Next() {
+		var key seriesKey
+		var b []byte
+		if err := rows.Scan(&key.linkID, &key.bucket, &b); err != nil {
+			return 0, err
+		}
+		days = append(days, key)
+
+		var day hll.Sketch
+		if err := day.UnmarshalBinary(b); err != nil {
+			return 0, err
+		}
+		month := seriesKey{key.linkID, startOfMonth(key.bucket)}
+		if months[month] == nil {
+			months[month] = &day
+		} else if err := months[month].Merge(&day); err != nil {
+			return 0, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	for key, sketch := range months {
+		stored, err := loadSketch(ctx, tx, "month", key)
+		if err != nil {
+			return 0, err
+		}
+		if err := stored.Merge(sketch); err != nil {
+			return 0, err
+		}
+		if err := storeSketch(ctx, tx, "month", key, stored); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, key := range days {
+		_, err := tx.ExecContext(ctx, `DELETE FROM click_sketches WHERE link_id = $1 AND day = $2`, key.linkID, key.bucket)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(days), tx.Commit()
+}
+
+// querySeries reads rollup rows of link_id, bucket, clicks, uniques and bots.
+func querySeries(ctx context.Context, tx *sql.Tx, query string, args ...any) (map[seriesKey]*StatPoint, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make(map[seriesKey]*StatPoint)
+	for rows.Next() {
+		var key seriesKey
+		var p StatPoint
+		if err := rows.Scan(&key.linkID, &key.bucket, &p.Clicks, &p.Uniques, &p.Bots); err != nil {
+			return nil, err
+		}
+		points[key] = &p
+	}
+
+	return points, rows.Err()
+}
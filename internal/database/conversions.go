@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Conversion is an event, such as a signup, attributed to a click.
+type Conversion struct {
+	ID        int       `json:"id"`
+	ClickID   string    `json:"click_id"`
+	LinkID    int       `json:"-"`
+	Event     string    `json:"event"`
+	Value     float64   `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConversionSummary reports the conversions of a link.
+type ConversionSummary struct {
+	// Clicks is the number of click IDs issued for the link. Click IDs
+	// expire with their raw clicks, conversions are kept.
+	Clicks int `json:"clicks"`
+	// ConvertedClicks is the number of those click IDs with at least one
+	// conversion.
+	ConvertedClicks int            `json:"converted_clicks"`
+	ConversionRate  float64        `json:"conversion_rate"`
+	Conversions     int            `json:"conversions"`
+	Value           float64        `json:"value"`
+	Events          []EventSummary `json:"events"`
+}
+
+// EventSummary reports the conversions of one event name.
+type EventSummary struct {
+	Event       string  `json:"event"`
+	Conversions int     `json:"conversions"`
+	Value       float64 `json:"value"`
+}
+
+// ConversionStore persists conversions and attributes them to clicks.
+type ConversionStore interface {
+	// LinkIDForClick returns the link of the click a click ID was issued
+	// for. Returns sql.ErrNoRows if the click ID is unknown, not written
+	// yet or expired with its click.
+	LinkIDForClick(clickID string) (int, error)
+	// Create inserts a conversion and sets its ID and creation time.
+	Create(c *Conversion) error
+	// Summary reports the click IDs and conversions of a link.
+	Summary(linkID int) (*ConversionSummary, error)
+}
+
+// ConversionModel stores conversions in SQLite.
+type ConversionModel struct {
+	DB *sql.DB
+}
+
+var _ ConversionStore = (*ConversionModel)(nil)
+
+// LinkIDForClick returns the link of the click a click ID was issued for.
+// Returns sql.ErrNoRows if the click ID is unknown, not written yet or
+// expired with its click.
+func (m ConversionModel) LinkIDForClick(clickID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// Matching only non-empty click IDs lets SQLite use the partial index.
+	query := `SELECT link_id FROM clicks WHERE click_id = $1 AND click_id != ''`
+
+	var linkID int
+	err := m.DB.QueryRowContext(ctx, query, clickID).Scan(&linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, err
+	}
+	return linkID, nil
+}
+
+// Create records a conversion.
+func (m *ConversionModel) Create(c *Conversion) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO conversions (click_id, link_id, event, value)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return m.DB.QueryRowContext(ctx, query, c.ClickID, c.LinkID, c.Event, c.Value).Scan(&c.ID, &c.CreatedAt)
+}
+
+// Summary reports the click IDs and conversions of a link.
+func (m ConversionModel) Summary(linkID int) (*ConversionSummary, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	summary := ConversionSummary{Events: []EventSummary{}}
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM clicks WHERE link_id = $1 AND click_id != ''),
+			COUNT(DISTINCT c.click_id),
+			COUNT(*),
+			COALESCE(SUM(cv.value), 0)
+		FROM conversions cv
+		LEFT JOIN clicks c ON c.click_id = cv.click_id AND c.click_id != ''
+		WHERE cv.link_id = $1
+	`
+	err := m.DB.QueryRowContext(ctx, query, linkID).Scan(&summary.Clicks, &summary.ConvertedClicks,
+		&summary.Conversions, &summary.Value)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Clicks > 0 {
+		summary.ConversionRate = float64(summary.ConvertedClicks) / float64(summary.Clicks)
+	}
+
+	query = `
+		SELECT event, COUNT(*), COALESCE(SUM(value), 0)
+		FROM conversions
+		WHERE link_id = $1
+		GROUP BY event
+		ORDER BY COUNT(*) DESC, event
+	`
+	rows, err := m.DB.QueryContext(ctx, query, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e EventSummary
+		if err := rows.Scan(&e.Event, &e.Conversions, &e.Value); err != nil {
+			return nil, err
+		}
+		summary.Events = append(summary.Events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
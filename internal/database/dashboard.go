@@ -0,0 +1,283 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Totals counts what the instance holds.
+type Totals struct {
+	Links        int `json:"links"`
+	ExpiredLinks int `json:"expired_links"`
+	// Clicks counts the human clicks of all links.
+	Clicks     int `json:"clicks"`
+	Workspaces int `json:"workspaces"`
+	Domains    int `json:"domains"`
+
+	// ExpiringSoon counts the links expiring within a day, a week and
+	// 30 days.
+	ExpiringSoon struct {
+		Day   int `json:"24h"`
+		Week  int `json:"7d"`
+		Month int `json:"30d"`
+	} `json:"expiring_soon"`
+}
+
+// DayCount is a number of events on the UTC day starting at Time.
+type DayCount struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// LinkCount is the number of clicks of a link in a window. Baseline holds
+// the clicks of the window before it when the two are compared.
+type LinkCount struct {
+	Code        string `json:"code"`
+	ShortURL    string `json:"short_url"`
+	OriginalURL string `json:"original_url"`
+	WorkspaceID int    `json:"workspace_id,omitempty"`
+	Clicks      int    `json:"clicks"`
+	Baseline    int    `json:"baseline,omitempty"`
+}
+
+// DomainCount is the number of links to a destination domain and their
+// human clicks.
+type DomainCount struct {
+	Domain string `json:"domain"`
+	Links  int    `json:"links"`
+	Clicks int    `json:"clicks"`
+}
+
+// DashboardStore reads instance-wide statistics for operators. Clicks are
+// counted from the hourly and daily rollups; days already compacted into
+// monthly rollups are left out.
+type DashboardStore interface {
+	// Totals counts the links, clicks, workspaces and domains of the
+	// instance and the links expiring soon after now.
+	Totals(now time.Time) (*Totals, error)
+	// LinksPerDay returns the number of links created on the UTC days in
+	// [from, to) that have any, in order.
+	LinksPerDay(from, to time.Time) ([]DayCount, error)
+	// ClicksPerDay returns the clicks of all links on the UTC days in
+	// [from, to) that have any, in order. Bots counts bot clicks, which
+	// are not included in Clicks.
+	ClicksPerDay(from, to time.Time) ([]StatPoint, error)
+	// TopLinks returns the n links with the most human clicks in
+	// [from, to).
+	TopLinks(from, to time.Time, n int) ([]LinkCount, error)
+	// LinkVelocity returns the links with human clicks in [since, to).
+	// Their clicks in [from, since) are returned as their baseline.
+	LinkVelocity(from, since, to time.Time) ([]LinkCount, error)
+	// TopDestinations returns the n destination domains with the most
+	// links, ignoring a leading "www.".
+	TopDestinations(n int) ([]DomainCount, error)
+}
+
+// DashboardModel reads the dashboard statistics from SQLite.
+type DashboardModel struct {
+	DB *sql.DB
+}
+
+var _ DashboardStore = (*DashboardModel)(nil)
+
+// Totals counts the links, clicks, workspaces and domains of the instance
+// and the links expiring soon after now.
+func (m DashboardModel) Totals(now time.Time) (*Totals, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Expiry times are in unix milliseconds, 0 for links that never expire.
+	ms := now.UnixMilli()
+	day := (24 * time.Hour).Milliseconds()
+
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE expires_at > 0 AND expires_at <= $1), COALESCE(SUM(clicks), 0),
+			COUNT(*) FILTER (WHERE expires_at > $1 AND expires_at <= $2),
+			COUNT(*) FILTER (WHERE expires_at > $1 AND expires_at <= $3),
+			COUNT(*) FILTER (WHERE expires_at > $1 AND expires_at <= $4),
+			(SELECT COUNT(*) FROM workspaces), (SELECT COUNT(*) FROM domains)
+		FROM links
+	`
+
+	var t Totals
+	err := m.DB.QueryRowContext(ctx, query, ms, ms+day, ms+7*day, ms+30*day).Scan(&t.Links, &t.ExpiredLinks,
+		&t.Clicks, &t.ExpiringSoon.Day, &t.ExpiringSoon.Week, &t.ExpiringSoon.Month, &t.Workspaces, &t.Domains)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// LinksPerDay returns the number of links created on the UTC days in
+// [from, to) that have any, in order.
+func (m DashboardModel) LinksPerDay(from, to time.Time) ([]DayCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT day, COUNT(*)
+		FROM (SELECT CAST(strftime('%s', created_at) AS INTEGER) / 86400 * 86400 AS day FROM links)
+		WHERE day >= $1 AND day < $2
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []DayCount{}
+	for rows.Next() {
+		var c DayCount
+		var unix int64
+		if err := rows.Scan(&unix, &c.Count); err != nil {
+			return nil, err
+		}
+		c.Time = time.Unix(unix, 0).UTC()
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// ClicksPerDay returns the clicks of all links on the UTC days in [from, to)
+// that have any, in order. Bots counts bot clicks, which are not included
+// in Clicks.
+func (m DashboardModel) ClicksPerDay(from, to time.Time) ([]StatPoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT day, SUM(clicks), SUM(uniques), SUM(bots)
+		FROM (
+			SELECT hour / 86400 * 86400 AS day, clicks, uniques, bots FROM click_stats_hour
+			WHERE hour >= $1 AND hour < $2
+			UNION ALL
+			SELECT day, clicks, uniques, bots FROM click_stats_day
+			WHERE day >= $1 AND day < $2
+		)
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []StatPoint
+	for rows.Next() {
+		var p StatPoint
+		var unix int64
+		if err := rows.Scan(&unix, &p.Clicks, &p.Uniques, &p.Bots); err != nil {
+			return nil, err
+		}
+		p.Time = time.Unix(unix, 0).UTC()
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// TopLinks returns the n links with the most human clicks in [from, to).
+func (m DashboardModel) TopLinks(from, to time.Time, n int) ([]LinkCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT l.code, d.scheme || '://' || d.host || '/' || l.code, l.original_url, l.workspace_id,
+			SUM(r.clicks), 0
+		FROM (
+			SELECT link_id, clicks FROM click_stats_hour WHERE hour >= $1 AND hour < $2
+			UNION ALL
+			SELECT link_id, clicks FROM click_stats_day WHERE day >= $1 AND day < $2
+		) r
+		JOIN links l ON l.id = r.link_id
+		JOIN domains d ON d.id = l.domain_id
+		GROUP BY r.link_id
+		HAVING SUM(r.clicks) > 0
+		ORDER BY SUM(r.clicks) DESC, r.link_id
+		LIMIT $3
+	`
+
+	return m.queryLinkCounts(ctx, query, from.Unix(), to.Unix(), n)
+}
+
+// LinkVelocity returns the links with human clicks in [since, to). Their
+// clicks in [from, since) are returned as their baseline.
+func (m DashboardModel) LinkVelocity(from, since, to time.Time) ([]LinkCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT l.code, d.scheme || '://' || d.host || '/' || l.code, l.original_url, l.workspace_id,
+			SUM(r.clicks) FILTER (WHERE r.bucket >= $1), COALESCE(SUM(r.clicks) FILTER (WHERE r.bucket < $1), 0)
+		FROM (
+			SELECT link_id, hour AS bucket, clicks FROM click_stats_hour WHERE hour >= $2 AND hour < $3
+			UNION ALL
+			SELECT link_id, day, clicks FROM click_stats_day WHERE day >= $2 AND day < $3
+		) r
+		JOIN links l ON l.id = r.link_id
+		JOIN domains d ON d.id = l.domain_id
+		GROUP BY r.link_id
+		HAVING SUM(r.clicks) FILTER (WHERE r.bucket >= $1) > 0
+	`
+
+	return m.queryLinkCounts(ctx, query, since.Unix(), from.Unix(), to.Unix())
+}
+
+func (m DashboardModel) queryLinkCounts(ctx context.Context, query string, args ...any) ([]LinkCount, error) {
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []LinkCount{}
+	for rows.Next() {
+		var c LinkCount
+		if err := rows.Scan(&c.Code, &c.ShortURL, &c.OriginalURL, &c.WorkspaceID, &c.Clicks, &c.Baseline); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// TopDestinations returns the n destination domains with the most links,
+// ignoring a leading "www.".
+func (m DashboardModel) TopDestinations(n int) ([]DomainCount, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT destination_host, COUNT(*), COALESCE(SUM(clicks), 0)
+		FROM links
+		WHERE destination_host != ''
+		GROUP BY destination_host
+		ORDER BY COUNT(*) DESC, SUM(clicks) DESC, destination_host
+		LIMIT $1
+	`
+	rows, err := m.DB.QueryContext(ctx, query, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []DomainCount{}
+	for rows.Next() {
+		var c DomainCount
+		if err := rows.Scan(&c.Domain, &c.Links, &c.Clicks); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DeepLinks holds the app targets a link opens on mobile platforms.
+type DeepLinks struct {
+	IOS     *AppTarget `json:"ios,omitempty"`
+	Android *AppTarget `json:"android,omitempty"`
+}
+
+// AppTarget is the app destination for one platform. URL is either a custom
+// scheme (myapp://item/42) or a universal/app link served over https.
+// StoreURL is where visitors without the app are sent, falling back to the
+// web destination of the link when empty.
+type AppTarget struct {
+	URL      string `json:"url"`
+	StoreURL string `json:"store_url,omitempty"`
+}
+
+// IsZero reports whether no platform has a deep link target.
+func (d DeepLinks) IsZero() bool {
+	return d.IOS == nil && d.Android == nil
+}
+
+// Validate checks the configured targets.
+func (d DeepLinks) Validate() error {
+	for platform, target := range map[string]*AppTarget{"ios": d.IOS, "android": d.Android} {
+		if target == nil {
+			continue
+		}
+		if err := target.validate(); err != nil {
+			return fmt.Errorf("%s: %w", platform, err)
+		}
+	}
+	return nil
+}
+
+func (t *AppTarget) validate() error {
+	u, err := url.Parse(t.URL)
+	if err != nil || u.Scheme == "" {
+		return fmt.Errorf("url must be an absolute URL with a scheme")
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "javascript", "data", "vbscript", "file", "blob":
+		return fmt.Errorf("url scheme %q is not allowed", u.Scheme)
+	case "http", "https":
+		if u.Host == "" {
+			return fmt.Errorf("url must have a host")
+		}
+	}
+
+	if t.StoreURL != "" {
+		s, err := url.Parse(t.StoreURL)
+		if err != nil || (s.Scheme != "http" && s.Scheme != "https") || s.Host == "" {
+			return fmt.Errorf("store_url must be an absolute http(s) URL")
+		}
+	}
+	return nil
+}
+
+// IsCustomScheme reports whether the target opens the app through a custom
+// URL scheme rather than a universal/app link.
+func (t *AppTarget) IsCustomScheme() bool {
+	u, err := url.Parse(t.URL)
+	return err == nil && u.Scheme != "http" && u.Scheme != "https"
+}
+
+// Value implements driver.Valuer.
+func (d DeepLinks) Value() (driver.Value, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (d *DeepLinks) Scan(src any) error {
+	return scanJSON(src, d)
+}
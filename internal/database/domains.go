@@ -0,0 +1,433 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDomainInUse is returned when deleting a domain that still has links.
+var ErrDomainInUse = errors.New("domain still has links")
+
+// Verification states of a domain.
+const (
+	DomainPending  = "pending"  // ownership has not been proven yet
+	DomainVerified = "verified" // ownership was proven on the last check
+	DomainFailing  = "failing"  // verified, but recent checks failed
+	DomainFailed   = "failed"   // checks failed for too long; traffic is refused
+)
+
+// Domain is a host short links are served from. Every domain has its own
+// namespace of codes.
+type Domain struct {
+	ID      int    `json:"-"`
+	Host    string `json:"host"`
+	Scheme  string `json:"scheme"`
+	Default bool   `json:"default,omitempty"`
+	// RootRedirect is where visitors of the bare domain are sent; the
+	// frontend is served when it is empty.
+	RootRedirect string `json:"root_redirect,omitempty"`
+	// NotFoundURL is where visitors of unknown or expired codes are sent; the
+	// frontend explains the error when it is empty.
+	NotFoundURL string `json:"not_found_url,omitempty"`
+	// LinkDefaults apply to links created on the domain.
+	LinkDefaults LinkDefaults `json:"link_defaults,omitzero"`
+	CreatedAt    time.Time    `json:"created_at"`
+
+	// WorkspaceID is the workspace that added the domain and may create links
+	// on it, 0 for domains the admin shares with everyone.
+	WorkspaceID int `json:"workspace_id,omitempty"`
+	// VerificationToken proves ownership of domains added by workspaces.
+	VerificationToken string `json:"verification_token,omitempty"`
+	// Status is one of the verification states.
+	Status            string     `json:"status"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	CheckedAt         *time.Time `json:"checked_at,omitempty"`
+	FailingSince      *time.Time `json:"failing_since,omitempty"`
+	VerificationError string     `json:"verification_error,omitempty"`
+}
+
+// Verified reports whether the domain may serve traffic.
+func (d *Domain) Verified() bool {
+	return d.VerifiedAt != nil
+}
+
+func (d *Domain) status() string {
+	switch {
+	case d.VerifiedAt != nil && d.FailingSince != nil:
+		return DomainFailing
+	case d.VerifiedAt != nil:
+		return DomainVerified
+	case d.VerificationError != "":
+		return DomainFailed
+	default:
+		return DomainPending
+	}
+}
+
+// RecordCheck updates the verification state with the outcome of a check
+// at now. A verified domain stays verified until checks have been failing
+// for grace, so brief DNS or network problems do not take it offline.
+func (d *Domain) RecordCheck(err error, now time.Time, grace time.Duration) {
+	d.CheckedAt = &now
+	if err == nil {
+		if d.VerifiedAt == nil {
+			d.VerifiedAt = &now
+		}
+		d.FailingSince, d.VerificationError = nil, ""
+	} else {
+		d.VerificationError = err.Error()
+		if d.FailingSince == nil {
+			d.FailingSince = &now
+		}
+		if d.VerifiedAt != nil && now.Sub(*d.FailingSince) >= grace {
+			d.VerifiedAt = nil
+		}
+	}
+	d.Status = d.status()
+}
+
+// DueForCheck reports whether the domain should be verified again at now.
+// Verified domains are checked every interval, others every retry.
+func (d *Domain) DueForCheck(now time.Time, interval, retry time.Duration) bool {
+	if d.CheckedAt == nil {
+		return true
+	}
+	if d.VerifiedAt == nil || d.FailingSince != nil {
+		interval = retry
+	}
+	return now.Sub(*d.CheckedAt) >= interval
+}
+
+// BaseURL returns the URL short codes of the domain are appended to.
+func (d *Domain) BaseURL() string {
+	return d.Scheme + "://" + d.Host
+}
+
+// ShortURL returns the short URL of code on the domain.
+func (d *Domain) ShortURL(code string) string {
+	return d.BaseURL() + "/" + code
+}
+
+// Validate checks that the domain is well formed.
+func (d *Domain) Validate() error {
+	if !validHost(d.Host) {
+		return errors.New("host must be a hostname with an optional port, e.g. go.example.com")
+	}
+	// Ownership checks connect to domains added by workspaces, so they must
+	// not name an address or port of their choosing.
+	if d.VerificationToken != "" && !plainHostname(d.Host) {
+		return errors.New("host of a custom domain must be a hostname without a port or IP address, e.g. go.example.com")
+	}
+	if d.Scheme != "http" && d.Scheme != "https" {
+		return errors.New("scheme must be http or https")
+	}
+	for name, u := range map[string]string{"root_redirect": d.RootRedirect, "not_found_url": d.NotFoundURL} {
+		if u == "" {
+			continue
+		}
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s must be an absolute http(s) URL", name)
+		}
+	}
+	return nil
+}
+
+// NormalizeHost lowercases host and strips a trailing dot.
+func NormalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+func validHost(host string) bool {
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, ""
+	}
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return false
+		}
+	}
+	return name != "" && !strings.ContainsAny(name, "/?#@ ") && name == NormalizeHost(name)
+}
+
+// plainHostname reports whether host is a name without a port rather than
+// an IP address.
+func plainHostname(host string) bool {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return false
+	}
+	_, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	return err != nil && !strings.Contains(host, ":")
+}
+
+// LinkDefaults are settings applied to new links of a domain that do not
+// configure them explicitly.
+type LinkDefaults struct {
+	// ExpiresIn is the lifetime of new links in seconds, 0 for no expiry.
+	ExpiresIn  int             `json:"expires_in,omitempty" validate:"min=0"`
+	Forwarding Forwarding      `json:"forwarding,omitzero"`
+	Redirect   RedirectOptions `json:"redirect,omitzero"`
+}
+
+// IsZero reports whether no defaults are configured.
+func (ld LinkDefaults) IsZero() bool {
+	return ld.ExpiresIn == 0 && ld.Forwarding.IsZero() && ld.Redirect.IsZero()
+}
+
+// Apply fills the settings link leaves unset with the defaults.
+func (ld LinkDefaults) Apply(link *Link) {
+	if link.ExpiresAt == 0 && ld.ExpiresIn > 0 {
+		link.ExpiresAt = int(time.Now().Add(time.Duration(ld.ExpiresIn) * time.Second).UnixMilli())
+	}
+	if link.Forwarding.IsZero() {
+		link.Forwarding = ld.Forwarding
+	}
+	if link.Redirect.IsZero() {
+		link.Redirect = ld.Redirect
+	}
+}
+
+// Value implements driver.Valuer.
+func (ld LinkDefaults) Value() (driver.Value, error) {
+	b, err := json.Marshal(ld)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (ld *LinkDefaults) Scan(src any) error {
+	return scanJSON(src, ld)
+}
+
+// DomainStore persists domains.
+type DomainStore interface {
+	// SetDefault points the default domain at baseURL, e.g.
+	// "https://short.link".
+	SetDefault(baseURL string) error
+	// Create inserts a domain and sets its ID, creation time and status.
+	Create(d *Domain) error
+	// Update saves the settings of a domain: its redirects, link defaults
+	// and workspace. Returns sql.ErrNoRows if the domain does not exist.
+	Update(d *Domain) error
+	// UpdateVerification saves the verification state of a domain.
+	UpdateVerification(d *Domain) error
+	// Default returns the default domain.
+	Default() (*Domain, error)
+	// GetByHost returns the domain serving host, which may carry a port. A
+	// domain registered with the exact port is preferred over one without.
+	// Returns sql.ErrNoRows if no domain matches.
+	GetByHost(host string) (*Domain, error)
+	// List returns all domains, the default domain first, then by host.
+	List() ([]*Domain, error)
+	// Delete removes a domain without links. The default domain cannot be
+	// deleted. Returns sql.ErrNoRows if it does not exist and
+	// ErrDomainInUse while links still use it.
+	Delete(id int) error
+}
+
+// DomainModel stores domains in SQLite.
+type DomainModel struct {
+	DB *sql.DB
+}
+
+var _ DomainStore = (*DomainModel)(nil)
+
+const domainColumns = `
+	id, host, scheme, is_default, root_redirect, not_found_url, link_defaults, created_at,
+	workspace_id, verification_token, verified_at, checked_at, failing_since, verification_error`
+
+func scanDomain(row rowScanner) (*Domain, error) {
+	var d Domain
+	err := row.Scan(&d.ID, &d.Host, &d.Scheme, &d.Default, &d.RootRedirect, &d.NotFoundURL, &d.LinkDefaults,
+		&d.CreatedAt, &d.WorkspaceID, &d.VerificationToken, &d.VerifiedAt, &d.CheckedAt, &d.FailingSince,
+		&d.VerificationError)
+	if err != nil {
+		return nil, err
+	}
+	d.Status = d.status()
+	return &d, nil
+}
+
+// SetDefault points the default domain at baseURL, e.g. "https://short.link".
+func (m DomainModel) SetDefault(baseURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid default domain %q", baseURL)
+	}
+
+	query := `
+		UPDATE domains
+		SET host = $1, scheme = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE is_default = 1
+	`
+	_, err = m.DB.ExecContext(ctx, query, NormalizeHost(u.Host), u.Scheme)
+	return err
+}
+
+// Create inserts a new domain.
+func (m *DomainModel) Create(d *Domain) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO domains (host, scheme, root_redirect, not_found_url, link_defaults, workspace_id,
+			verification_token, verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	err := m.DB.QueryRowContext(ctx, query, d.Host, d.Scheme, d.RootRedirect, d.NotFoundURL,
+		d.LinkDefaults, d.WorkspaceID, d.VerificationToken, d.VerifiedAt).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return err
+	}
+	d.Status = d.status()
+	return nil
+}
+
+// Update saves the settings of a domain.
+// Returns sql.ErrNoRows if the domain does not exist.
+func (m *DomainModel) Update(d *Domain) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE domains
+		SET root_redirect = $1, not_found_url = $2, link_defaults = $3, workspace_id = $4,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+	`
+	result, err := m.DB.ExecContext(ctx, query, d.RootRedirect, d.NotFoundURL, d.LinkDefaults, d.WorkspaceID,
+		d.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateVerification saves the verification state of a domain.
+func (m DomainModel) UpdateVerification(d *Domain) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE domains
+		SET verified_at = $1, checked_at = $2, failing_since = $3, verification_error = $4
+		WHERE id = $5
+	`
+	_, err := m.DB.ExecContext(ctx, query, d.VerifiedAt, d.CheckedAt, d.FailingSince, d.VerificationError, d.ID)
+	return err
+}
+
+// Default returns the default domain.
+func (m DomainModel) Default() (*Domain, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + domainColumns + ` FROM domains WHERE is_default = 1`
+	return scanDomain(m.DB.QueryRowContext(ctx, query))
+}
+
+// GetByHost returns the domain serving host, which may carry a port. A
+// domain registered with the exact port is preferred over one without.
+// Returns sql.ErrNoRows if no domain matches.
+func (m DomainModel) GetByHost(host string) (*Domain, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	host = NormalizeHost(host)
+	name, _, err := net.SplitHostPort(host)
+	if err != nil {
+		name = host
+	}
+
+	query := `
+		SELECT ` + domainColumns + `
+		FROM domains
+		WHERE host IN ($1, $2) AND host != ''
+		ORDER BY length(host) DESC
+		LIMIT 1
+	`
+	return scanDomain(m.DB.QueryRowContext(ctx, query, host, name))
+}
+
+// List returns all domains, the default domain first.
+func (m DomainModel) List() ([]*Domain, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + domainColumns + ` FROM domains ORDER BY is_default DESC, host`
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := []*Domain{}
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+// Delete removes a domain without links. The default domain cannot be
+// deleted. Returns sql.ErrNoRows if it does not exist and ErrDomainInUse
+// while links still use it.
+func (m DomainModel) Delete(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var inUse bool
+	err := m.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM links WHERE domain_id = $1)`, id).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrDomainInUse
+	}
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM domains WHERE id = $1 AND is_default = 0`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func TestDomainValidateHost(t *testing.T) {
+	tests := []struct {
+		host   string
+		custom bool
+		valid  bool
+	}{
+		{"go.example.com", false, true},
+		{"go.example.com", true, true},
+		{"localhost:8080", false, true},
+		{"localhost:8080", true, false},
+		{"go.example.com:443", true, false},
+		{"127.0.0.1", false, true},
+		{"127.0.0.1", true, false},
+		{"169.254.169.254", true, false},
+		{"[::1]", true, false},
+		{"::1", true, false},
+		{"go.example.com/x", false, false},
+	}
+
+	for _, tt := range tests {
+		d := database.Domain{Host: tt.host, Scheme: "https"}
+		if tt.custom {
+			d.VerificationToken = "tok"
+		}
+		if err := d.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate() of %q (custom %v) = %v, want valid %v", tt.host, tt.custom, err, tt.valid)
+		}
+	}
+}
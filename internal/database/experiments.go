@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"time"
+)
+
+// Limits applied to experiments.
+const (
+	MaxVariants      = 10
+	MaxVariantWeight = 1000
+)
+
+// Experiment splits the default destination of a link between weighted
+// variants. Visitors are assigned a variant once and keep it.
+type Experiment struct {
+	// ID changes whenever a new experiment starts, which resets assignments
+	// and click counts.
+	ID        string    `json:"id"`
+	Variants  []Variant `json:"variants"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Variant is one destination of an experiment.
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// Validate checks the variants of an experiment.
+func (e *Experiment) Validate() error {
+	if len(e.Variants) < 2 || len(e.Variants) > MaxVariants {
+		return fmt.Errorf("an experiment needs between 2 and %d variants", MaxVariants)
+	}
+
+	total := 0
+	seen := make(map[string]bool, len(e.Variants))
+	for _, v := range e.Variants {
+		if !IsIdentifier(v.Name) {
+			return fmt.Errorf("variant name %q must be 1-32 letters, digits, '-' or '_'", v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variant name %q", v.Name)
+		}
+		seen[v.Name] = true
+
+		u, err := url.Parse(v.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("variant %q must have an absolute http(s) URL", v.Name)
+		}
+		if v.Weight < 0 || v.Weight > MaxVariantWeight {
+			return fmt.Errorf("variant %q weight must be between 0 and %d", v.Name, MaxVariantWeight)
+		}
+		total += v.Weight
+	}
+	if total == 0 {
+		return errors.New("at least one variant needs a positive weight")
+	}
+	return nil
+}
+
+// IsIdentifier reports whether name is 1-32 ASCII letters, digits, '-' or
+// '_', which is safe to use in cookies and query parameter names.
+func IsIdentifier(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// Variant returns the named variant if it is still receiving traffic.
+func (e *Experiment) Variant(name string) (*Variant, bool) {
+	for i := range e.Variants {
+		if e.Variants[i].Name == name && e.Variants[i].Weight > 0 {
+			return &e.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
+// Pick chooses a variant at random in proportion to the weights.
+func (e *Experiment) Pick() *Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return &e.Variants[0]
+	}
+
+	n := rand.IntN(total)
+	for i := range e.Variants {
+		n -= e.Variants[i].Weight
+		if n < 0 {
+			return &e.Variants[i]
+		}
+	}
+	return &e.Variants[len(e.Variants)-1]
+}
+
+// Value implements driver.Valuer; a nil experiment is stored as NULL.
+func (e *Experiment) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// VariantClicks returns the number of human clicks of each variant of an
+// experiment.
+func (m ClickModel) VariantClicks(linkID int, experimentID string) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT variant, clicks
+		FROM experiment_clicks
+		WHERE link_id = $1 AND experiment_id = $2
+	`
+	rows, err := m.DB.QueryContext(ctx, query, linkID, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clicks := make(map[string]int)
+	for rows.Next() {
+		var (
+			variant string
+			n       int
+		)
+		if err := rows.Scan(&variant, &n); err != nil {
+			return nil, err
+		}
+		clicks[variant] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clicks, nil
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidPathSuffix is returned by Forwarding.Apply for path suffixes
+// containing dot segments.
+var ErrInvalidPathSuffix = errors.New("invalid path suffix")
+
+// Policies for query parameters present in both the request and the
+// destination URL.
+const (
+	QueryConflictOverride = "override" // the request value replaces the destination value
+	QueryConflictKeep     = "keep"     // the destination value is kept
+	QueryConflictAppend   = "append"   // both values are kept
+)
+
+// Forwarding controls which parts of the short URL request are carried over
+// to the destination.
+type Forwarding struct {
+	// Path forwards anything after the code, so /docs/intro on a link to
+	// https://example.com/guide redirects to https://example.com/guide/intro.
+	Path bool `json:"path,omitempty"`
+	// Query merges the request query string into the destination.
+	Query bool `json:"query,omitempty"`
+	// QueryConflict is one of the QueryConflict policies, override if empty.
+	QueryConflict string `json:"query_conflict,omitempty" validate:"omitempty,oneof=override keep append"`
+}
+
+// IsZero reports whether nothing is forwarded.
+func (f Forwarding) IsZero() bool {
+	return !f.Path && !f.Query && f.QueryConflict == ""
+}
+
+// Apply returns destination with the escaped path suffix and query of the
+// request merged in as configured.
+func (f Forwarding) Apply(destination, suffix string, query url.Values) (string, error) {
+	if (!f.Path || suffix == "") && (!f.Query || len(query) == 0) {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	if f.Path && suffix != "" {
+		// Dot segments would let the suffix climb out of the destination
+		// path. Browsers resolve escaped dots like %2e%2e as well, and treat
+		// backslashes as slashes, so segments are checked unescaped.
+		unescaped, err := url.PathUnescape(suffix)
+		if err != nil {
+			return "", ErrInvalidPathSuffix
+		}
+		for segment := range strings.FieldsFuncSeq(unescaped, isPathSeparator) {
+			if segment == ".." || segment == "." {
+				return "", ErrInvalidPathSuffix
+			}
+		}
+		u = u.JoinPath(suffix)
+	}
+
+	if f.Query && len(query) > 0 {
+		merged := u.Query()
+		for name, values := range query {
+			switch f.QueryConflict {
+			case QueryConflictKeep:
+				if !merged.Has(name) {
+					merged[name] = values
+				}
+			case QueryConflictAppend:
+				merged[name] = append(merged[name], values...)
+			default:
+				merged[name] = values
+			}
+		}
+		u.RawQuery = merged.Encode()
+	}
+
+	return u.String(), nil
+}
+
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// Value implements driver.Valuer.
+func (f Forwarding) Value() (driver.Value, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (f *Forwarding) Scan(src any) error {
+	return scanJSON(src, f)
+}
@@ -0,0 +1,68 @@
+package database_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func TestForwardingApplyPath(t *testing.T) {
+	f := database.Forwarding{Path: true}
+	const destination = "https://example.com/docs/v1"
+
+	tests := []struct {
+		suffix string
+		want   string
+	}{
+		{"", destination},
+		{"intro", "https://example.com/docs/v1/intro"},
+		{"guide/intro", "https://example.com/docs/v1/guide/intro"},
+		{"a%20b", "https://example.com/docs/v1/a%20b"},
+		{"..a/b..", "https://example.com/docs/v1/..a/b.."},
+	}
+	for _, tt := range tests {
+		got, err := f.Apply(destination, tt.suffix, nil)
+		if err != nil || got != tt.want {
+			t.Errorf("Apply(%q) = %q, %v, want %q", tt.suffix, got, err, tt.want)
+		}
+	}
+
+	for _, suffix := range []string{
+		"..", "../admin", "a/../../admin", "./x", "a/.",
+		"%2e%2e/admin", "%2E%2E/admin", ".%2e/admin", "%2e/admin", "x/%2e%2E",
+		"..%2fadmin", "..%5cadmin", "..\\admin", "%zz",
+	} {
+		if got, err := f.Apply(destination, suffix, nil); !errors.Is(err, database.ErrInvalidPathSuffix) {
+			t.Errorf("Apply(%q) = %q, %v, want ErrInvalidPathSuffix", suffix, got, err)
+		}
+	}
+}
+
+func TestForwardingApplyQuery(t *testing.T) {
+	const destination = "https://example.com/?a=1&b=2"
+	query := url.Values{"a": {"9"}, "c": {"3"}}
+
+	tests := []struct {
+		conflict string
+		want     string
+	}{
+		{"", "https://example.com/?a=9&b=2&c=3"},
+		{database.QueryConflictOverride, "https://example.com/?a=9&b=2&c=3"},
+		{database.QueryConflictKeep, "https://example.com/?a=1&b=2&c=3"},
+		{database.QueryConflictAppend, "https://example.com/?a=1&a=9&b=2&c=3"},
+	}
+	for _, tt := range tests {
+		f := database.Forwarding{Query: true, QueryConflict: tt.conflict}
+		got, err := f.Apply(destination, "", query)
+		if err != nil || got != tt.want {
+			t.Errorf("Apply() with conflict policy %q = %q, %v, want %q", tt.conflict, got, err, tt.want)
+		}
+	}
+
+	// Nothing is forwarded unless enabled.
+	if got, err := (database.Forwarding{Path: true}).Apply(destination, "", query); err != nil || got != destination {
+		t.Errorf("Apply() without query forwarding = %q, %v, want %q", got, err, destination)
+	}
+}
@@ -3,59 +3,300 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/netip"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/joybiswas007/linkshort/internal/routing"
 )
 
+// maxPasswordBytes is the longest password bcrypt hashes.
+const maxPasswordBytes = 72
+
+// ErrPasswordTooLong is returned by SetPassword for passwords bcrypt cannot
+// hash.
+var ErrPasswordTooLong = errors.New("password must be at most 72 bytes")
+
 // Link represents a shortened URL entry with metadata.
 type Link struct {
 	ID          int       `json:"-"`
 	Code        string    `json:"code"`
 	ShortURL    string    `json:"short_url"`
+	DomainID    int       `json:"-"`
+	Domain      string    `json:"domain"`
 	OriginalURL string    `json:"original_url"`
 	ExpiresAt   int       `json:"expires_at,omitempty"`
 	CreatedAt   time.Time `json:"-"`
 	UpdatedAt   time.Time `json:"-"`
+
+	// PasswordHash is the bcrypt hash of the link password, empty when the
+	// link is not password protected.
+	PasswordHash string `json:"-"`
+	// PasswordProtected reports whether visitors must enter a password.
+	PasswordProtected bool `json:"password_protected,omitempty"`
+
+	// WorkspaceID is the owning workspace, 0 for anonymous links.
+	WorkspaceID int `json:"workspace_id,omitempty"`
+	// AccessPolicy restricts who may resolve the link.
+	AccessPolicy AccessPolicy `json:"access_policy,omitzero"`
+	// Rules route matching visitors away from OriginalURL, which remains the
+	// default destination.
+	Rules RoutingRules `json:"rules,omitempty"`
+	// Experiment splits traffic to the default destination between variants.
+	Experiment *Experiment `json:"experiment,omitempty"`
+	// DeepLinks opens the link in a mobile app on supported platforms.
+	DeepLinks DeepLinks `json:"deep_links,omitzero"`
+	// Forwarding carries the request path suffix and query to the destination.
+	Forwarding Forwarding `json:"forwarding,omitzero"`
+
+	// BaseURL is the destination before UTM parameters were added, empty
+	// when the link carries no UTM values.
+	BaseURL string `json:"base_url,omitempty"`
+	// UTM holds the campaign parameters appended to BaseURL.
+	UTM UTM `json:"utm,omitzero"`
+	// DestinationHost is the host of OriginalURL, lowercased and without a
+	// port or a leading "www.". It is set by SetDestination.
+	DestinationHost string `json:"-"`
+
+	// ClickIDParam is the destination query parameter that carries a unique
+	// click ID per redirect, so conversions can be attributed to the click.
+	// Empty disables click IDs.
+	ClickIDParam string `json:"click_id_param,omitempty"`
+
+	// Interstitial fires workspace tracking snippets before redirecting.
+	Interstitial Interstitial `json:"interstitial,omitzero"`
+
+	// Clicks counts redirects served for the link.
+	Clicks int `json:"-"`
+	// PublicStats shows the click count on the link preview page.
+	PublicStats bool `json:"public_stats,omitempty"`
+
+	// Redirect sets the status code and headers of the redirect response.
+	Redirect RedirectOptions `json:"redirect,omitzero"`
+}
+
+// RoutingRules is the ordered list of routing rules of a link, stored as JSON.
+type RoutingRules []routing.Rule
+
+// Value implements driver.Valuer.
+func (r RoutingRules) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (r *RoutingRules) Scan(src any) error {
+	return scanJSON(src, r)
+}
+
+// AccessPolicy restricts a link to visitors from the allowed CIDR ranges or,
+// when WorkspaceOnly is set, to members of the owning workspace. A visitor
+// satisfying any configured condition is granted access; an empty policy
+// allows everyone.
+type AccessPolicy struct {
+	AllowedCIDRs  []string `json:"allowed_cidrs,omitempty" validate:"omitempty,dive,cidr"`
+	WorkspaceOnly bool     `json:"workspace_only,omitempty"`
+}
+
+// IsZero reports whether the policy places no restriction on the link.
+func (p AccessPolicy) IsZero() bool {
+	return len(p.AllowedCIDRs) == 0 && !p.WorkspaceOnly
+}
+
+// AllowsIP reports whether ip falls within one of the allowed CIDR ranges.
+func (p AccessPolicy) AllowsIP(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, cidr := range p.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer, storing the policy as JSON.
+func (p AccessPolicy) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner for policies stored as JSON.
+func (p *AccessPolicy) Scan(src any) error {
+	return scanJSON(src, p)
+}
+
+// jsonColumn scans a nullable JSON text column into dst, leaving dst
+// untouched for NULL.
+type jsonColumn struct {
+	dst any
+}
+
+// Scan implements sql.Scanner.
+func (c jsonColumn) Scan(src any) error {
+	return scanJSON(src, c.dst)
+}
+
+// scanJSON decodes a JSON text column into dst.
+func scanJSON(src, dst any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	case []byte:
+		return json.Unmarshal(v, dst)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dst)
+	}
+}
+
+// SetPassword hashes and stores the given plaintext password on the link.
+// An empty password removes the protection. Passwords longer than 72 bytes
+// return ErrPasswordTooLong.
+func (l *Link) SetPassword(plaintext string) error {
+	if plaintext == "" {
+		l.PasswordHash = ""
+		l.PasswordProtected = false
+		return nil
+	}
+	if len(plaintext) > maxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(plaintext), 12)
+	if err != nil {
+		return err
+	}
+
+	l.PasswordHash = string(hash)
+	l.PasswordProtected = true
+	return nil
+}
+
+// PasswordMatches reports whether the plaintext password matches the stored hash.
+func (l *Link) PasswordMatches(plaintext string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(l.PasswordHash), []byte(plaintext))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
-// LinkModel provides database operations for shortened links.
+// LinkStore persists links. Handlers depend on it instead of a database,
+// so links can be kept elsewhere or faked in tests.
+type LinkStore interface {
+	// Create inserts a link and sets its ID and creation time. Codes are
+	// unique per domain.
+	Create(link *Link) error
+	// Update saves the mutable fields of the link with link.ID; its code,
+	// domain and workspace stay. Returns sql.ErrNoRows for a missing link.
+	Update(link *Link) error
+	// Exists reports whether code is taken on a domain.
+	Exists(domainID int, code string) (bool, error)
+	// GetByCode returns the link with code on a domain, its short URL set.
+	// Returns sql.ErrNoRows if there is none.
+	GetByCode(domainID int, code string) (*Link, error)
+	// List returns the links matching filter, newest first, at most
+	// filter.Limit of them or 50 when it is not set.
+	List(filter LinkFilter) ([]*Link, error)
+	// WorkspaceIDOf returns the workspace owning a link, 0 for links
+	// without one. Returns sql.ErrNoRows for a missing link.
+	WorkspaceIDOf(id int) (int, error)
+}
+
+// LinkModel stores links in SQLite.
 type LinkModel struct {
 	DB *sql.DB
 }
 
-// Create inserts a new shortened URL into the database and returns the generated ID.
-// It returns an error if the insert fails.
+var _ LinkStore = (*LinkModel)(nil)
+
+// Create inserts a new shortened URL into the database and sets the ID and
+// creation time of link. It returns an error if the insert fails.
 func (m *LinkModel) Create(link *Link) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	query := `
-		INSERT INTO links (code, short_url, original_url, expires_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO links (code, domain_id, original_url, expires_at, password_hash, workspace_id, access_policy,
+			routing_rules, experiment, deep_links, forwarding, base_url, utm_source, utm_medium, utm_campaign,
+			utm_term, utm_content, click_id_param, interstitial, public_stats, redirect_options, destination_host)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		RETURNING id, created_at, updated_at
 	`
-	stmt, err := m.DB.PrepareContext(ctx, query)
+	return m.DB.QueryRowContext(ctx, query, link.Code, link.DomainID, link.OriginalURL, link.ExpiresAt,
+		link.PasswordHash, link.WorkspaceID, link.AccessPolicy, link.Rules, link.Experiment, link.DeepLinks,
+		link.Forwarding, link.BaseURL, link.UTM.Source, link.UTM.Medium, link.UTM.Campaign, link.UTM.Term,
+		link.UTM.Content, link.ClickIDParam, link.Interstitial, link.PublicStats,
+		link.Redirect, link.DestinationHost).Scan(&link.ID, &link.CreatedAt, &link.UpdatedAt)
+}
+
+// Update saves the mutable fields of an existing link.
+// Returns sql.ErrNoRows if the link no longer exists.
+func (m *LinkModel) Update(link *Link) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE links
+		SET original_url = $1, expires_at = $2, password_hash = $3, access_policy = $4,
+			routing_rules = $5, experiment = $6, deep_links = $7, forwarding = $8,
+			base_url = $9, utm_source = $10, utm_medium = $11, utm_campaign = $12, utm_term = $13,
+			utm_content = $14, click_id_param = $15, interstitial = $16, public_stats = $17,
+			redirect_options = $18, destination_host = $19, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $20
+	`
+
+	result, err := m.DB.ExecContext(ctx, query, link.OriginalURL, link.ExpiresAt, link.PasswordHash,
+		link.AccessPolicy, link.Rules, link.Experiment, link.DeepLinks, link.Forwarding,
+		link.BaseURL, link.UTM.Source, link.UTM.Medium, link.UTM.Campaign, link.UTM.Term, link.UTM.Content,
+		link.ClickIDParam, link.Interstitial, link.PublicStats, link.Redirect, link.DestinationHost, link.ID)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, link.Code, link.ShortURL, link.OriginalURL, link.ExpiresAt)
+	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
 
-// Exists checks whether a short code is already in use.
+// Exists checks whether a short code is already in use on a domain.
 // Returns true if the code exists, false otherwise.
-func (m LinkModel) Exists(code string) (bool, error) {
+func (m LinkModel) Exists(domainID int, code string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT EXISTS(SELECT 1 FROM links WHERE code = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM links WHERE domain_id = $1 AND code = $2)`
 
 	var exists bool
-	err := m.DB.QueryRowContext(ctx, query, code).Scan(&exists)
+	err := m.DB.QueryRowContext(ctx, query, domainID, code).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
@@ -63,27 +304,72 @@ func (m LinkModel) Exists(code string) (bool, error) {
 	return exists, nil
 }
 
-// GetByCode retrieves a shortened link by its unique code.
-// Returns sql.ErrNoRows if the code does not exist.
-func (m LinkModel) GetByCode(code string) (*Link, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// linkColumns lists the columns read by scanLink, in order, selected from
+// linkTables. The short URL is derived from the link's domain.
+const linkColumns = `
+	l.id, l.code, d.scheme || '://' || d.host || '/' || l.code, l.domain_id, d.host,
+	l.original_url, l.expires_at, l.password_hash,
+	l.workspace_id, l.access_policy, l.routing_rules, l.experiment, l.deep_links, l.forwarding,
+	l.base_url, l.utm_source, l.utm_medium, l.utm_campaign, l.utm_term, l.utm_content, l.destination_host, l.click_id_param,
+	l.interstitial, l.clicks, l.public_stats, l.redirect_options, l.created_at, l.updated_at`
 
-	const query = `
-        SELECT id, code, short_url, original_url, expires_at
-        FROM links
-        WHERE code = $1
-    `
+const linkTables = `links l JOIN domains d ON d.id = l.domain_id`
 
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanLink reads a row selected with linkColumns.
+func scanLink(row rowScanner) (*Link, error) {
 	var l Link
-	// If expires_at can be NULL in DB, prefer sql.NullTime or *time.Time in the struct.
-	err := m.DB.QueryRowContext(ctx, query, code).Scan(
+	err := row.Scan(
 		&l.ID,
 		&l.Code,
 		&l.ShortURL,
+		&l.DomainID,
+		&l.Domain,
 		&l.OriginalURL,
 		&l.ExpiresAt,
+		&l.PasswordHash,
+		&l.WorkspaceID,
+		&l.AccessPolicy,
+		&l.Rules,
+		jsonColumn{&l.Experiment},
+		&l.DeepLinks,
+		&l.Forwarding,
+		&l.BaseURL,
+		&l.UTM.Source,
+		&l.UTM.Medium,
+		&l.UTM.Campaign,
+		&l.UTM.Term,
+		&l.UTM.Content,
+		&l.DestinationHost,
+		&l.ClickIDParam,
+		&l.Interstitial,
+		&l.Clicks,
+		&l.PublicStats,
+		&l.Redirect,
+		&l.CreatedAt,
+		&l.UpdatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	l.PasswordProtected = l.PasswordHash != ""
+
+	return &l, nil
+}
+
+// GetByCode retrieves a shortened link by its code on a domain.
+// Returns sql.ErrNoRows if the code does not exist.
+func (m LinkModel) GetByCode(domainID int, code string) (*Link, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + linkColumns + ` FROM ` + linkTables + ` WHERE l.domain_id = $1 AND l.code = $2`
+
+	l, err := scanLink(m.DB.QueryRowContext(ctx, query, domainID, code))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, sql.ErrNoRows
@@ -91,5 +377,77 @@ func (m LinkModel) GetByCode(code string) (*Link, error) {
 		return nil, err
 	}
 
-	return &l, nil
+	return l, nil
+}
+
+// LinkFilter narrows the links returned by List. Zero fields are ignored.
+type LinkFilter struct {
+	WorkspaceID int
+	DomainID    int
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+	Limit       int
+	Offset      int
+}
+
+// List returns the links matching filter, newest first.
+func (m LinkModel) List(filter LinkFilter) ([]*Link, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + linkColumns + `
+		FROM ` + linkTables + `
+		WHERE ($1 = 0 OR l.workspace_id = $1)
+			AND ($2 = 0 OR l.domain_id = $2)
+			AND ($3 = '' OR l.utm_source = $3)
+			AND ($4 = '' OR l.utm_medium = $4)
+			AND ($5 = '' OR l.utm_campaign = $5)
+		ORDER BY l.id DESC
+		LIMIT $6 OFFSET $7
+	`
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := m.DB.QueryContext(ctx, query, filter.WorkspaceID, filter.DomainID, filter.UTMSource,
+		filter.UTMMedium, filter.UTMCampaign, limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*Link{}
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// WorkspaceIDOf returns the owning workspace of a link, 0 for anonymous links.
+// Returns sql.ErrNoRows if the link does not exist.
+func (m LinkModel) WorkspaceIDOf(id int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var workspaceID int
+	err := m.DB.QueryRowContext(ctx, `SELECT workspace_id FROM links WHERE id = $1`, id).Scan(&workspaceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, err
+	}
+	return workspaceID, nil
 }
@@ -0,0 +1,55 @@
+package database_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func TestSetPasswordLength(t *testing.T) {
+	// 72 bytes is the most bcrypt hashes, however many characters they are.
+	var link database.Link
+	if err := link.SetPassword(strings.Repeat("a", 72)); err != nil {
+		t.Errorf("SetPassword() of 72 bytes = %v", err)
+	}
+	if err := link.SetPassword(strings.Repeat("ä", 37)); !errors.Is(err, database.ErrPasswordTooLong) {
+		t.Errorf("SetPassword() of 74 bytes = %v, want ErrPasswordTooLong", err)
+	}
+}
+
+func TestSetDestinationScheme(t *testing.T) {
+	for _, dest := range []string{"https://example.com/a", "HTTP://example.com"} {
+		var link database.Link
+		if err := link.SetDestination(dest, database.UTM{Source: "news"}); err != nil {
+			t.Errorf("SetDestination(%q) = %v", dest, err)
+		}
+	}
+	for _, dest := range []string{"javascript:alert(1)", "data:text/html,<script>", "vbscript:x", "https:///path", "//example.com"} {
+		var link database.Link
+		if err := link.SetDestination(dest, database.UTM{}); !errors.Is(err, database.ErrInvalidDestination) {
+			t.Errorf("SetDestination(%q) = %v, want ErrInvalidDestination", dest, err)
+		}
+	}
+}
+
+func TestSetDestinationHost(t *testing.T) {
+	tests := []struct {
+		dest string
+		utm  database.UTM
+		want string
+	}{
+		{"https://example.com/a", database.UTM{}, "example.com"},
+		{"https://WWW.Example.com:8443/a?b=c", database.UTM{}, "example.com"},
+		{"http://user@www.example.com", database.UTM{Source: "news"}, "example.com"},
+		{"https://wwwexample.com", database.UTM{}, "wwwexample.com"},
+		{"http://[::1]:8080/", database.UTM{}, "::1"},
+	}
+	for _, tt := range tests {
+		var link database.Link
+		if err := link.SetDestination(tt.dest, tt.utm); err != nil || link.DestinationHost != tt.want {
+			t.Errorf("SetDestination(%q) set host %q, %v, want %q", tt.dest, link.DestinationHost, err, tt.want)
+		}
+	}
+}
@@ -14,9 +14,18 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// Models contains all database models.
+// Models holds the stores of all entities. NewModels returns the SQLite
+// implementations; other backends provide their own, which must pass the
+// conformance suite in package storetest.
 type Models struct {
-	Links LinkModel
+	Links        LinkStore
+	Workspaces   WorkspaceStore
+	UTMTemplates UTMTemplateStore
+	Conversions  ConversionStore
+	Snippets     SnippetStore
+	Domains      DomainStore
+	Clicks       ClickStore
+	Dashboard    DashboardStore
 }
 
 // New creates a new database connection to an SQLite database.
@@ -36,10 +45,17 @@ func New(dbName string) (*sql.DB, error) {
 	return db, nil
 }
 
-// NewModels initializes all database models.
+// NewModels returns the SQLite stores of all entities.
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Links: LinkModel{DB: db},
+		Links:        &LinkModel{DB: db},
+		Workspaces:   &WorkspaceModel{DB: db},
+		UTMTemplates: &UTMTemplateModel{DB: db},
+		Conversions:  &ConversionModel{DB: db},
+		Snippets:     &SnippetModel{DB: db},
+		Domains:      &DomainModel{DB: db},
+		Clicks:       &ClickModel{DB: db},
+		Dashboard:    &DashboardModel{DB: db},
 	}
 }
 
@@ -0,0 +1,46 @@
+package database_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/database/storetest"
+)
+
+// newTestDB returns a migrated database in which storetest.Domains exist.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "links.db")
+	db, err := database.New(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are read from the working directory.
+	t.Chdir("../..")
+	if err := database.Migrate(name, db); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range storetest.Domains {
+		query := `
+			INSERT INTO domains (id, host, scheme, is_default) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO UPDATE SET host = excluded.host, scheme = excluded.scheme
+		`
+		if _, err := db.Exec(query, d.ID, d.Host, d.Scheme, d.Default); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+func TestModels(t *testing.T) {
+	storetest.TestModels(t, func(t *testing.T) database.Models {
+		return database.NewModels(newTestDB(t))
+	})
+}
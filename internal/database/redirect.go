@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+)
+
+// RedirectOptions controls the response sent when a link redirects.
+type RedirectOptions struct {
+	// Status is the redirect status code, 302 Found if zero.
+	Status int `json:"status,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	// MaxAge lets caches store the redirect for this many seconds. Shared
+	// caches are only allowed to when the redirect is the same for every
+	// visitor; see Link.VariesByVisitor.
+	MaxAge int `json:"max_age,omitempty" validate:"min=0,max=31536000"`
+	// NoIndex asks search engines not to index the short URL.
+	NoIndex bool `json:"noindex,omitempty"`
+	// ReferrerPolicy is sent as the Referrer-Policy header, e.g. "no-referrer"
+	// to hide the short URL from the destination.
+	ReferrerPolicy string `json:"referrer_policy,omitempty" validate:"omitempty,oneof=no-referrer no-referrer-when-downgrade origin origin-when-cross-origin same-origin strict-origin strict-origin-when-cross-origin unsafe-url"`
+}
+
+// IsZero reports whether all defaults apply.
+func (o RedirectOptions) IsZero() bool {
+	return o == RedirectOptions{}
+}
+
+// StatusCode returns the configured status, http.StatusFound by default.
+func (o RedirectOptions) StatusCode() int {
+	if o.Status == 0 {
+		return http.StatusFound
+	}
+	return o.Status
+}
+
+// Value implements driver.Valuer.
+func (o RedirectOptions) Value() (driver.Value, error) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (o *RedirectOptions) Scan(src any) error {
+	return scanJSON(src, o)
+}
+
+// VariesByVisitor reports whether visitors of the link may be sent to
+// different destinations or be stopped before the redirect, in which case
+// the response must not be stored by shared caches.
+func (l *Link) VariesByVisitor() bool {
+	return l.PasswordProtected || !l.AccessPolicy.IsZero() || len(l.Rules) > 0 || l.Experiment != nil ||
+		!l.DeepLinks.IsZero() || l.ClickIDParam != "" || l.Interstitial.Enabled
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kinds of snippets fired on interstitial pages.
+const (
+	SnippetPixel  = "pixel"  // an image request to URL
+	SnippetScript = "script" // an external script loaded from URL
+	SnippetInline = "inline" // inline JavaScript in Code
+)
+
+// Snippet is a tracking pixel or script a workspace fires on interstitial
+// pages before visitors continue to the destination.
+type Snippet struct {
+	ID          int    `json:"id"`
+	WorkspaceID int    `json:"-"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	URL         string `json:"url,omitempty"`
+	Code        string `json:"code,omitempty"`
+	// Hosts lists additional hosts the snippet loads from or sends data to,
+	// such as the hosts contacted by inline code. They are allowed by the
+	// Content-Security-Policy of the interstitial page.
+	Hosts     []string  `json:"hosts,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that the snippet is well formed.
+func (s *Snippet) Validate() error {
+	if s.Name == "" || len(s.Name) > 100 {
+		return errors.New("name must be 1-100 characters")
+	}
+
+	switch s.Kind {
+	case SnippetPixel, SnippetScript:
+		u, err := url.Parse(s.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("url must be an absolute https URL")
+		}
+		if s.Code != "" {
+			return fmt.Errorf("code is not allowed for %s snippets", s.Kind)
+		}
+	case SnippetInline:
+		if s.Code == "" || len(s.Code) > 16384 {
+			return errors.New("code must be 1-16384 characters")
+		}
+		if strings.Contains(strings.ToLower(s.Code), "</script") {
+			return errors.New("code must not contain a closing script tag")
+		}
+		if s.URL != "" {
+			return errors.New("url is not allowed for inline snippets")
+		}
+	default:
+		return fmt.Errorf("kind must be one of %s, %s or %s", SnippetPixel, SnippetScript, SnippetInline)
+	}
+
+	for _, host := range s.Hosts {
+		if !validCSPHost(host) {
+			return fmt.Errorf("invalid host %q, use a hostname such as cdn.example.com or *.example.com", host)
+		}
+	}
+	return nil
+}
+
+// CSPHosts returns the https sources the snippet needs, keyed by the
+// Content-Security-Policy directive that must allow them.
+func (s *Snippet) CSPHosts() map[string][]string {
+	sources := map[string][]string{}
+	add := func(directive, host string) {
+		sources[directive] = append(sources[directive], "https://"+host)
+	}
+
+	if u, err := url.Parse(s.URL); err == nil && u.Host != "" {
+		switch s.Kind {
+		case SnippetPixel:
+			add("img-src", u.Host)
+		case SnippetScript:
+			add("script-src", u.Host)
+			add("connect-src", u.Host)
+		}
+	}
+	for _, host := range s.Hosts {
+		add("script-src", host)
+		add("img-src", host)
+		add("connect-src", host)
+	}
+	return sources
+}
+
+// validCSPHost accepts hostnames with an optional leading wildcard label.
+func validCSPHost(host string) bool {
+	host = strings.TrimPrefix(host, "*.")
+	if host == "" || len(host) > 253 || !strings.Contains(host, ".") {
+		return false
+	}
+	for _, c := range host {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// Interstitial configures the page shown before redirecting, which fires
+// the workspace snippets and then continues to the destination.
+type Interstitial struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// DelayMS is how long the page waits before continuing.
+	DelayMS int `json:"delay_ms,omitempty" validate:"min=0,max=10000"`
+	// SnippetIDs selects the snippets to fire; empty fires all of them.
+	SnippetIDs []int `json:"snippet_ids,omitempty"`
+}
+
+// IsZero reports whether the interstitial is unconfigured.
+func (i Interstitial) IsZero() bool {
+	return !i.Enabled && i.DelayMS == 0 && len(i.SnippetIDs) == 0
+}
+
+// Value implements driver.Valuer.
+func (i Interstitial) Value() (driver.Value, error) {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (i *Interstitial) Scan(src any) error {
+	return scanJSON(src, i)
+}
+
+// SnippetStore persists the snippets of workspaces.
+type SnippetStore interface {
+	// Create inserts a snippet and sets its ID and creation time.
+	Create(s *Snippet) error
+	// List returns the snippets of a workspace in creation order.
+	List(workspaceID int) ([]*Snippet, error)
+	// Delete removes a snippet of a workspace.
+	// Returns sql.ErrNoRows if the workspace has no such snippet.
+	Delete(workspaceID, id int) error
+}
+
+// SnippetModel stores snippets in SQLite.
+type SnippetModel struct {
+	DB *sql.DB
+}
+
+var _ SnippetStore = (*SnippetModel)(nil)
+
+// Create inserts a new snippet.
+func (m *SnippetModel) Create(s *Snippet) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	hosts, err := json.Marshal(s.Hosts)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO snippets (workspace_id, name, kind, url, code, hosts)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return m.DB.QueryRowContext(ctx, query, s.WorkspaceID, s.Name, s.Kind, s.URL, s.Code,
+		string(hosts)).Scan(&s.ID, &s.CreatedAt)
+}
+
+// List returns the snippets of a workspace in creation order.
+func (m SnippetModel) List(workspaceID int) ([]*Snippet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, workspace_id, name, kind, url, code, hosts, created_at
+		FROM snippets
+		WHERE workspace_id = $1
+		ORDER BY id
+	`
+	rows, err := m.DB.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := []*Snippet{}
+	for rows.Next() {
+		var s Snippet
+		err := rows.Scan(&s.ID, &s.WorkspaceID, &s.Name, &s.Kind, &s.URL, &s.Code, jsonColumn{&s.Hosts},
+			&s.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+// Delete removes a snippet of a workspace.
+// Returns sql.ErrNoRows if it does not exist.
+func (m SnippetModel) Delete(workspaceID, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM snippets WHERE workspace_id = $1 AND id = $2`,
+		workspaceID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package storetest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// hour is the start of the hour the clicks of the suite are recorded in.
+var hour = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+func testClicks(t *testing.T, newModels func(t *testing.T) database.Models) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, m database.Models)
+	}{
+		{"Statistics", testStatistics},
+		{"Compaction", testCompaction},
+		{"Variants", testVariants},
+		{"ListAndDelete", testListAndDelete},
+		{"Salt", testSalt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newModels(t))
+		})
+	}
+}
+
+// record creates a link with code and stores clicks on it.
+func record(t *testing.T, m database.Models, code string, clicks ...*database.Click) *database.Link {
+	t.Helper()
+
+	link := create(t, m.Links, &database.Link{Code: code, DomainID: 1, OriginalURL: "https://example.com"})
+	for _, c := range clicks {
+		c.LinkID, c.Code = link.ID, code
+	}
+	if err := m.Clicks.InsertBatch(clicks); err != nil {
+		t.Fatalf("InsertBatch(): %v", err)
+	}
+	return link
+}
+
+// sampleClicks returns two human visitors, one of them returning, and a bot.
+func sampleClicks() []*database.Click {
+	return []*database.Click{
+		{ClickedAt: hour.Add(time.Minute), Visitor: "v1", Country: "DE"},
+		{ClickedAt: hour.Add(time.Minute + time.Second), Visitor: "v1", Country: "DE"},
+		{ClickedAt: hour.Add(2 * time.Minute), Visitor: "v2", Country: "US"},
+		{ClickedAt: hour.Add(2 * time.Minute), Visitor: "b", Country: "US", IsBot: true, Bot: "crawler"},
+	}
+}
+
+func testStatistics(t *testing.T, m database.Models) {
+	link := record(t, m, "stats", sampleClicks()...)
+	store := m.Clicks
+
+	got, err := store.Series(link.ID, time.Minute, hour, hour.Add(time.Hour), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []database.StatPoint{
+		{Time: hour.Add(time.Minute), Clicks: 2, Uniques: 1},
+		{Time: hour.Add(2 * time.Minute), Clicks: 1, Uniques: 1},
+	}
+	if !equalPoints(got, want) {
+		t.Errorf("Series() = %+v, want %+v", got, want)
+	}
+	if got, err := store.Series(link.ID, time.Hour, hour, hour.Add(time.Hour), true); err != nil || sumClicks(got) != 4 {
+		t.Errorf("Series() with bots = %+v, %v, want 4 clicks", got, err)
+	}
+	if got, err := store.Series(link.ID, time.Minute, hour.Add(time.Hour), hour.Add(2*time.Hour), true); err != nil || len(got) != 0 {
+		t.Errorf("Series() of an hour without clicks = %+v, %v", got, err)
+	}
+
+	top, err := store.Top(link.ID, database.DimensionCountry, hour, hour.Add(time.Hour), 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []database.StatCount{{Value: "DE", Clicks: 2, Uniques: 1}, {Value: "US", Clicks: 1, Uniques: 1}}; !reflect.DeepEqual(top, want) {
+		t.Errorf("Top() = %+v, want %+v", top, want)
+	}
+	// Ties are broken by value.
+	top, err = store.Top(link.ID, database.DimensionCountry, hour, hour.Add(time.Hour), 1, true)
+	if want := []database.StatCount{{Value: "DE", Clicks: 2, Uniques: 1}}; err != nil || !reflect.DeepEqual(top, want) {
+		t.Errorf("Top() with bots = %+v, %v, want %+v", top, err, want)
+	}
+
+	if n, err := store.Uniques(link.ID, hour, hour.Add(time.Hour)); err != nil || n != 2 {
+		t.Errorf("Uniques() = %d, %v, want 2", n, err)
+	}
+}
+
+func testCompaction(t *testing.T, m database.Models) {
+	link := record(t, m, "compact", sampleClicks()...)
+	store := m.Clicks
+	from, to := hour.AddDate(0, -1, 0), hour.AddDate(0, 1, 0)
+
+	// Compacted clicks are still counted, at a coarser resolution.
+	check := func(step string) {
+		t.Helper()
+
+		if got, err := store.Series(link.ID, time.Hour, from, to, false); err != nil || sumClicks(got) != 3 {
+			t.Errorf("Series() after %s = %+v, %v, want 3 clicks", step, got, err)
+		}
+		top, err := store.Top(link.ID, database.DimensionCountry, from, to, 10, false)
+		if want := []database.StatCount{{Value: "DE", Clicks: 2, Uniques: 1}, {Value: "US", Clicks: 1, Uniques: 1}}; err != nil || !reflect.DeepEqual(top, want) {
+			t.Errorf("Top() after %s = %+v, %v, want %+v", step, top, err, want)
+		}
+		if n, err := store.Uniques(link.ID, from, to); err != nil || n != 2 {
+			t.Errorf("Uniques() after %s = %d, %v, want 2", step, n, err)
+		}
+	}
+
+	if n, err := store.CompactHours(hour, 10); err != nil || n != 0 {
+		t.Errorf("CompactHours() before the clicks = %d, %v", n, err)
+	}
+	if n, err := store.CompactHours(hour.Add(time.Hour), 10); err != nil || n != 1 {
+		t.Errorf("CompactHours() = %d, %v, want 1", n, err)
+	}
+	check("CompactHours()")
+
+	if n, err := store.CompactDays(hour.AddDate(0, 0, 1), 10); err != nil || n == 0 {
+		t.Errorf("CompactDays() = %d, %v", n, err)
+	}
+	check("CompactDays()")
+}
+
+func testVariants(t *testing.T, m database.Models) {
+	link := record(t, m, "ab",
+		&database.Click{ClickedAt: hour, ExperimentID: "exp1", Variant: "a"},
+		&database.Click{ClickedAt: hour, ExperimentID: "exp1", Variant: "b"},
+		&database.Click{ClickedAt: hour, ExperimentID: "exp1", Variant: "a"},
+	)
+	more := []*database.Click{
+		{LinkID: link.ID, Code: "ab", ClickedAt: hour, ExperimentID: "exp1", Variant: "b", IsBot: true},
+		{LinkID: link.ID, Code: "ab", ClickedAt: hour, ExperimentID: "exp0", Variant: "a"},
+		{LinkID: link.ID, Code: "ab", ClickedAt: hour},
+	}
+	if err := m.Clicks.InsertBatch(more); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bot clicks are not counted.
+	got, err := m.Clicks.VariantClicks(link.ID, "exp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"a": 2, "b": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("VariantClicks() = %v, want %v", got, want)
+	}
+
+	// A new experiment starts without clicks.
+	if got, err := m.Clicks.VariantClicks(link.ID, "exp2"); err != nil || len(got) != 0 {
+		t.Errorf("VariantClicks() of a new experiment = %v, %v", got, err)
+	}
+}
+
+func testListAndDelete(t *testing.T, m database.Models) {
+	store := m.Clicks
+	if id, err := store.LastID(); err != nil || id != 0 {
+		t.Errorf("LastID() without clicks = %d, %v", id, err)
+	}
+
+	old := record(t, m, "old", &database.Click{ClickedAt: hour, Referrer: "https://ref.example/", ClickID: "c1"})
+	w := workspace(t, m.Workspaces, "team")
+	recent := create(t, m.Links, &database.Link{Code: "new", DomainID: 1, OriginalURL: "https://example.com", WorkspaceID: w.ID})
+	batch := []*database.Click{
+		{LinkID: recent.ID, Code: "new", ClickedAt: hour.Add(time.Hour), ExperimentID: "exp1", Variant: "a"},
+		{LinkID: recent.ID, Code: "new", ClickedAt: hour.Add(2 * time.Hour), IsBot: true, Bot: "crawler"},
+	}
+	if err := store.InsertBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := store.LastID()
+	if err != nil || last == 0 {
+		t.Fatalf("LastID() = %d, %v", last, err)
+	}
+
+	all, err := store.List(database.ClickFilter{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[2].ID != last {
+		t.Fatalf("List() = %+v, want 3 clicks ending with %d", all, last)
+	}
+	first := all[0]
+	if first.LinkID != old.ID || first.Code != "old" || !first.ClickedAt.Equal(hour) || first.ClickID != "c1" ||
+		first.Referrer != "https://ref.example/" {
+		t.Errorf("List() returned %+v", first)
+	}
+	if all[1].WorkspaceID != w.ID || all[1].Variant != "a" || !all[2].IsBot || all[2].Bot != "crawler" {
+		t.Errorf("List() returned %+v and %+v", all[1], all[2])
+	}
+
+	ids := func(filter database.ClickFilter, limit int) []int {
+		t.Helper()
+
+		clicks, err := store.List(filter, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []int{}
+		for _, c := range clicks {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+	tests := []struct {
+		filter database.ClickFilter
+		limit  int
+		want   []int
+	}{
+		{database.ClickFilter{}, 2, []int{all[0].ID, all[1].ID}},
+		{database.ClickFilter{LinkID: recent.ID}, 10, []int{all[1].ID, all[2].ID}},
+		{database.ClickFilter{WorkspaceID: w.ID}, 10, []int{all[1].ID, all[2].ID}},
+		{database.ClickFilter{From: hour.Add(time.Hour), To: hour.Add(2 * time.Hour)}, 10, []int{all[1].ID}},
+		{database.ClickFilter{AfterID: all[0].ID, UntilID: all[1].ID}, 10, []int{all[1].ID}},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.filter, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("List(%+v, %d) = %v, want %v", tt.filter, tt.limit, got, tt.want)
+		}
+	}
+
+	// The last click is kept even when it is old enough.
+	if n, err := store.DeleteClicks(hour.Add(time.Hour), 10); err != nil || n != 1 {
+		t.Errorf("DeleteClicks() = %d, %v, want 1", n, err)
+	}
+	if n, err := store.DeleteClicks(hour.AddDate(1, 0, 0), 10); err != nil || n != 1 {
+		t.Errorf("DeleteClicks() of all clicks = %d, %v, want 1", n, err)
+	}
+	if got := ids(database.ClickFilter{}, 10); !reflect.DeepEqual(got, []int{last}) {
+		t.Errorf("List() after DeleteClicks() = %v, want %v", got, []int{last})
+	}
+	if id, err := store.LastID(); err != nil || id != last {
+		t.Errorf("LastID() after DeleteClicks() = %d, %v, want %d", id, err, last)
+	}
+}
+
+func testSalt(t *testing.T, m database.Models) {
+	store := m.Clicks
+
+	today, err := store.Salt(hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(today) == 0 {
+		t.Fatal("Salt() is empty")
+	}
+	if again, err := store.Salt(hour.Add(13 * time.Hour)); err != nil || !reflect.DeepEqual(again, today) {
+		t.Errorf("Salt() later that day = %x, %v, want %x", again, err, today)
+	}
+	if tomorrow, err := store.Salt(hour.AddDate(0, 0, 1)); err != nil || reflect.DeepEqual(tomorrow, today) {
+		t.Errorf("Salt() of the next day = %x, %v, want a new salt", tomorrow, err)
+	}
+}
+
+func equalPoints(got, want []database.StatPoint) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		g, w := got[i], want[i]
+		if !g.Time.Equal(w.Time) || g.Clicks != w.Clicks || g.Uniques != w.Uniques || g.Bots != w.Bots {
+			return false
+		}
+	}
+	return true
+}
+
+func sumClicks(points []database.StatPoint) int {
+	n := 0
+	for _, p := range points {
+		n += p.Clicks
+	}
+	return n
+}
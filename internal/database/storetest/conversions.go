@@ -0,0 +1,83 @@
+package storetest
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func testConversions(t *testing.T, newModels func(t *testing.T) database.Models) {
+	m := newModels(t)
+	store := m.Conversions
+
+	link := record(t, m, "conv",
+		&database.Click{ClickedAt: hour, ClickID: "c1"},
+		&database.Click{ClickedAt: hour, ClickID: "c2"},
+		&database.Click{ClickedAt: hour},
+	)
+
+	if id, err := store.LinkIDForClick("c1"); err != nil || id != link.ID {
+		t.Errorf("LinkIDForClick() = %d, %v, want %d", id, err, link.ID)
+	}
+	if _, err := store.LinkIDForClick("c9"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("LinkIDForClick() of an unknown click ID = %v, want sql.ErrNoRows", err)
+	}
+
+	for _, c := range []*database.Conversion{
+		{ClickID: "c1", LinkID: link.ID, Event: "signup"},
+		{ClickID: "c1", LinkID: link.ID, Event: "purchase", Value: 9.5},
+		{ClickID: "c2", LinkID: link.ID, Event: "purchase", Value: 0.5},
+	} {
+		if err := store.Create(c); err != nil {
+			t.Fatal(err)
+		}
+		if c.ID == 0 || c.CreatedAt.IsZero() {
+			t.Errorf("Create() set %+v", c)
+		}
+	}
+
+	got, err := store.Summary(link.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &database.ConversionSummary{
+		Clicks:          2,
+		ConvertedClicks: 2,
+		ConversionRate:  1,
+		Conversions:     3,
+		Value:           10,
+		Events: []database.EventSummary{
+			{Event: "purchase", Conversions: 2, Value: 10},
+			{Event: "signup", Conversions: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+
+	// Click IDs expire with their clicks; their conversions are kept. The
+	// last click is kept by DeleteClicks, so one of them remains.
+	if _, err := m.Clicks.DeleteClicks(hour.Add(time.Hour), 10); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"c1", "c2"} {
+		if _, err := store.LinkIDForClick(id); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("LinkIDForClick(%s) of a deleted click = %v, want sql.ErrNoRows", id, err)
+		}
+	}
+	got, err = store.Summary(link.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Clicks != 0 || got.ConvertedClicks != 0 || got.Conversions != 3 || got.Value != 10 {
+		t.Errorf("Summary() after DeleteClicks() = %+v", got)
+	}
+
+	if got, err := store.Summary(link.ID + 1000); err != nil || got.Conversions != 0 || len(got.Events) != 0 {
+		t.Errorf("Summary() of a link without conversions = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,95 @@
+package storetest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func testDashboard(t *testing.T, newModels func(t *testing.T) database.Models) {
+	m := newModels(t)
+	store := m.Dashboard
+	now := time.Now()
+
+	w := workspace(t, m.Workspaces, "team")
+	hot := create(t, m.Links, withDestination(t, &database.Link{Code: "hot", DomainID: 2, OriginalURL: "https://example.com/a", WorkspaceID: w.ID}))
+	cold := create(t, m.Links, withDestination(t, &database.Link{Code: "cold", DomainID: 1, OriginalURL: "https://www.example.com/b"}))
+	create(t, m.Links, withDestination(t, &database.Link{Code: "gone", DomainID: 1, OriginalURL: "https://other.example", ExpiresAt: int(now.Add(-time.Hour).UnixMilli())}))
+	create(t, m.Links, withDestination(t, &database.Link{Code: "soon", DomainID: 1, OriginalURL: "https://example.com/c", ExpiresAt: int(now.Add(time.Hour).UnixMilli())}))
+
+	clicks := sampleClicks()
+	for _, c := range clicks {
+		c.LinkID, c.Code = hot.ID, hot.Code
+	}
+	clicks = append(clicks, &database.Click{LinkID: cold.ID, Code: cold.Code, ClickedAt: hour.Add(-2 * time.Hour), Visitor: "v3"})
+	if err := m.Clicks.InsertBatch(clicks); err != nil {
+		t.Fatal(err)
+	}
+
+	totals, err := store.Totals(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := database.Totals{Links: 4, ExpiredLinks: 1, Clicks: 4, Workspaces: 1, Domains: 2}
+	want.ExpiringSoon.Day, want.ExpiringSoon.Week, want.ExpiringSoon.Month = 1, 1, 1
+	if *totals != want {
+		t.Errorf("Totals() = %+v, want %+v", *totals, want)
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	perDay, err := store.LinksPerDay(day.AddDate(0, 0, -1), day.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, d := range perDay {
+		n += d.Count
+	}
+	if n != 4 {
+		t.Errorf("LinksPerDay() = %+v, want 4 links", perDay)
+	}
+
+	clickDay := hour.Truncate(24 * time.Hour)
+	points, err := store.ClicksPerDay(clickDay, clickDay.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || !points[0].Time.Equal(clickDay) || points[0].Clicks != 4 || points[0].Bots != 1 {
+		t.Errorf("ClicksPerDay() = %+v, want 4 clicks and 1 bot on %v", points, clickDay)
+	}
+
+	top, err := store.TopLinks(hour.Add(-3*time.Hour), hour.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTop := []database.LinkCount{
+		{Code: "hot", ShortURL: "http://go.example/hot", OriginalURL: "https://example.com/a", WorkspaceID: w.ID, Clicks: 3},
+		{Code: "cold", ShortURL: "https://short.example/cold", OriginalURL: "https://www.example.com/b", Clicks: 1},
+	}
+	if !reflect.DeepEqual(top, wantTop) {
+		t.Errorf("TopLinks() = %+v, want %+v", top, wantTop)
+	}
+	if top, err := store.TopLinks(hour.Add(-3*time.Hour), hour.Add(time.Hour), 1); err != nil || len(top) != 1 || top[0].Code != "hot" {
+		t.Errorf("TopLinks() of 1 = %+v, %v", top, err)
+	}
+
+	// Links without recent clicks are left out.
+	velocity, err := store.LinkVelocity(hour.Add(-3*time.Hour), hour.Add(-time.Hour), hour.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(velocity) != 1 || velocity[0].Code != "hot" || velocity[0].Clicks != 3 || velocity[0].Baseline != 0 {
+		t.Errorf("LinkVelocity() = %+v, want hot with 3 clicks", velocity)
+	}
+
+	destinations, err := store.TopDestinations(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDestinations := []database.DomainCount{{Domain: "example.com", Links: 3, Clicks: 4}, {Domain: "other.example", Links: 1}}
+	if !reflect.DeepEqual(destinations, wantDestinations) {
+		t.Errorf("TopDestinations() = %+v, want %+v", destinations, wantDestinations)
+	}
+}
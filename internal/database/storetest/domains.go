@@ -0,0 +1,110 @@
+package storetest
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func testDomains(t *testing.T, newModels func(t *testing.T) database.Models) {
+	m := newModels(t)
+	store := m.Domains
+
+	if err := store.SetDefault("https://s.example:8080"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDefault("s.example"); err == nil {
+		t.Error("SetDefault() without a scheme succeeded")
+	}
+	def, err := store.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.ID != 1 || def.Host != "s.example:8080" || def.Scheme != "https" || !def.Default {
+		t.Errorf("Default() = %+v", def)
+	}
+
+	w := workspace(t, m.Workspaces, "team")
+	owned := &database.Domain{Host: "links.example", Scheme: "https", WorkspaceID: w.ID, VerificationToken: "tok"}
+	if err := store.Create(owned); err != nil {
+		t.Fatal(err)
+	}
+	if owned.ID == 0 || owned.CreatedAt.IsZero() || owned.Status != database.DomainPending {
+		t.Errorf("Create() set %+v", owned)
+	}
+	shared := &database.Domain{Host: "links.example:8443", Scheme: "https"}
+	if err := store.Create(shared); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(&database.Domain{Host: "links.example", Scheme: "http"}); err == nil {
+		t.Error("Create() of a taken host succeeded")
+	}
+
+	// The exact port wins over the bare host.
+	for host, want := range map[string]int{"LINKS.example:8443": shared.ID, "links.example:9000": owned.ID, "links.example": owned.ID} {
+		if got, err := store.GetByHost(host); err != nil || got.ID != want {
+			t.Errorf("GetByHost(%s) = %+v, %v, want domain %d", host, got, err, want)
+		}
+	}
+	if _, err := store.GetByHost("none.example"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByHost() of an unknown host = %v, want sql.ErrNoRows", err)
+	}
+
+	shared.RootRedirect, shared.NotFoundURL = "https://root.example", "https://404.example"
+	shared.LinkDefaults = database.LinkDefaults{ExpiresIn: 60, Redirect: database.RedirectOptions{Status: 301}}
+	if err := store.Update(shared); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetByHost("links.example:8443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RootRedirect != shared.RootRedirect || got.NotFoundURL != shared.NotFoundURL || got.LinkDefaults != shared.LinkDefaults {
+		t.Errorf("GetByHost() after Update() = %+v", got)
+	}
+	if err := store.Update(&database.Domain{ID: owned.ID + shared.ID + 1000}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Update() of a missing domain = %v, want sql.ErrNoRows", err)
+	}
+
+	now := time.Now().UTC()
+	owned.VerifiedAt, owned.CheckedAt = &now, &now
+	if err := store.UpdateVerification(owned); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.GetByHost(owned.Host); err != nil || got.Status != database.DomainVerified || got.VerificationToken != "tok" {
+		t.Errorf("GetByHost() after UpdateVerification() = %+v, %v", got, err)
+	}
+
+	domains, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := []string{}
+	for _, d := range domains {
+		hosts = append(hosts, d.Host)
+	}
+	if want := []string{"s.example:8080", "go.example", "links.example", "links.example:8443"}; !slices.Equal(hosts, want) {
+		t.Errorf("List() = %v, want %v", hosts, want)
+	}
+
+	create(t, m.Links, &database.Link{Code: "abc", DomainID: shared.ID, OriginalURL: "https://example.com"})
+	if err := store.Delete(shared.ID); !errors.Is(err, database.ErrDomainInUse) {
+		t.Errorf("Delete() of a domain with links = %v, want ErrDomainInUse", err)
+	}
+	if err := store.Delete(def.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Delete() of the default domain = %v, want sql.ErrNoRows", err)
+	}
+	if err := store.Delete(owned.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetByHost(owned.Host); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByHost() of a deleted domain = %v, want sql.ErrNoRows", err)
+	}
+	if err := store.Delete(owned.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Delete() of a missing domain = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,266 @@
+package storetest
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/routing"
+)
+
+func testLinks(t *testing.T, newModels func(t *testing.T) database.Models) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store database.LinkStore)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"Codes", testCodes},
+		{"Update", testUpdate},
+		{"List", testList},
+		{"WorkspaceIDOf", testWorkspaceIDOf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newModels(t).Links)
+		})
+	}
+}
+
+// create stores link and returns it as read back from the store.
+func create(t *testing.T, store database.LinkStore, link *database.Link) *database.Link {
+	t.Helper()
+
+	if err := store.Create(link); err != nil {
+		t.Fatalf("Create(%s): %v", link.Code, err)
+	}
+	got, err := store.GetByCode(link.DomainID, link.Code)
+	if err != nil {
+		t.Fatalf("GetByCode(%d, %s) after Create: %v", link.DomainID, link.Code, err)
+	}
+	return got
+}
+
+// withDestination sets the destination of link to its OriginalURL with
+// SetDestination, as the API does.
+func withDestination(t *testing.T, link *database.Link) *database.Link {
+	t.Helper()
+
+	if err := link.SetDestination(link.OriginalURL, link.UTM); err != nil {
+		t.Fatalf("SetDestination(%s): %v", link.OriginalURL, err)
+	}
+	return link
+}
+
+// fullLink returns a link with every stored field set.
+func fullLink() *database.Link {
+	link := &database.Link{
+		Code:         "full",
+		DomainID:     2,
+		OriginalURL:  "https://example.com/?utm_source=news",
+		ExpiresAt:    1893456000000,
+		WorkspaceID:  7,
+		AccessPolicy: database.AccessPolicy{AllowedCIDRs: []string{"10.0.0.0/8"}, WorkspaceOnly: true},
+		Rules: database.RoutingRules{{
+			Countries:   []string{"DE"},
+			Hours:       &routing.HourRange{From: 9, To: 17},
+			Query:       map[string]string{"ref": ""},
+			Destination: "https://example.de",
+		}},
+		Experiment: &database.Experiment{
+			ID:        "exp1",
+			Variants:  []database.Variant{{Name: "a", URL: "https://a.example", Weight: 50}, {Name: "b", URL: "https://b.example", Weight: 50}},
+			StartedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		DeepLinks:       database.DeepLinks{IOS: &database.AppTarget{URL: "app://item", StoreURL: "https://apps.example"}},
+		Forwarding:      database.Forwarding{Path: true, Query: true, QueryConflict: "keep"},
+		BaseURL:         "https://example.com/",
+		UTM:             database.UTM{Source: "news", Medium: "email", Campaign: "spring", Term: "t", Content: "c"},
+		ClickIDParam:    "cid",
+		DestinationHost: "example.com",
+		Interstitial:    database.Interstitial{Enabled: true, DelayMS: 500, SnippetIDs: []int{1, 2}},
+		PublicStats:     true,
+		Redirect:        database.RedirectOptions{Status: 301, MaxAge: 60, NoIndex: true, ReferrerPolicy: "no-referrer"},
+	}
+	if err := link.SetPassword("secret"); err != nil {
+		panic(err)
+	}
+	return link
+}
+
+func testCreateAndGet(t *testing.T, store database.LinkStore) {
+	want := fullLink()
+	got := create(t, store, want)
+
+	if got.ID == 0 {
+		t.Error("link has no ID")
+	}
+	if want.ID != got.ID || !want.CreatedAt.Equal(got.CreatedAt) {
+		t.Errorf("Create() set ID %d created at %v, stored %d created at %v", want.ID, want.CreatedAt, got.ID, got.CreatedAt)
+	}
+	if got.ShortURL != "http://go.example/full" || got.Domain != "go.example" {
+		t.Errorf("short URL = %q on %q, want http://go.example/full", got.ShortURL, got.Domain)
+	}
+	if got.CreatedAt.IsZero() {
+		t.Error("link has no creation time")
+	}
+	if !got.PasswordProtected {
+		t.Error("link is not password protected")
+	}
+	if ok, err := got.PasswordMatches("secret"); err != nil || !ok {
+		t.Errorf("PasswordMatches() = %v, %v", ok, err)
+	}
+
+	// Fields set by the store.
+	want.ID, want.ShortURL, want.Domain = got.ID, got.ShortURL, got.Domain
+	want.CreatedAt, want.UpdatedAt = got.CreatedAt, got.UpdatedAt
+	want.PasswordProtected = true
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetByCode() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	plain := create(t, store, &database.Link{Code: "plain", DomainID: 1, OriginalURL: "https://example.com"})
+	if plain.ShortURL != "https://short.example/plain" || plain.PasswordProtected || plain.Experiment != nil {
+		t.Errorf("link without options = %+v", plain)
+	}
+}
+
+func testCodes(t *testing.T, store database.LinkStore) {
+	if _, err := store.GetByCode(1, "abc"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByCode() of a missing code = %v, want sql.ErrNoRows", err)
+	}
+	if exists, err := store.Exists(1, "abc"); err != nil || exists {
+		t.Errorf("Exists() of a missing code = %v, %v", exists, err)
+	}
+
+	create(t, store, &database.Link{Code: "abc", DomainID: 1, OriginalURL: "https://one.example"})
+
+	if exists, err := store.Exists(1, "abc"); err != nil || !exists {
+		t.Errorf("Exists() after Create = %v, %v", exists, err)
+	}
+	if exists, err := store.Exists(2, "abc"); err != nil || exists {
+		t.Errorf("Exists() on another domain = %v, %v", exists, err)
+	}
+
+	// Codes are unique per domain only.
+	if err := store.Create(&database.Link{Code: "abc", DomainID: 1, OriginalURL: "https://dup.example"}); err == nil {
+		t.Error("Create() of a taken code succeeded")
+	}
+	other := create(t, store, &database.Link{Code: "abc", DomainID: 2, OriginalURL: "https://two.example"})
+	if other.OriginalURL != "https://two.example" {
+		t.Errorf("link on the second domain goes to %s", other.OriginalURL)
+	}
+
+	first, err := store.GetByCode(1, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.OriginalURL != "https://one.example" || first.ID == other.ID {
+		t.Errorf("link on the first domain = %+v", first)
+	}
+}
+
+func testUpdate(t *testing.T, store database.LinkStore) {
+	link := create(t, store, &database.Link{Code: "upd", DomainID: 1, OriginalURL: "https://old.example"})
+
+	want := fullLink()
+	want.ID, want.Code, want.DomainID = link.ID, link.Code, link.DomainID
+	if err := store.Update(want); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+	// Links stay in their workspace.
+	want.WorkspaceID = link.WorkspaceID
+
+	got, err := store.GetByCode(1, "upd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UpdatedAt.Before(got.CreatedAt) {
+		t.Errorf("updated at %v, before creation at %v", got.UpdatedAt, got.CreatedAt)
+	}
+	want.ShortURL, want.Domain = got.ShortURL, got.Domain
+	want.CreatedAt, want.UpdatedAt = got.CreatedAt, got.UpdatedAt
+	want.PasswordProtected = true
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetByCode() after Update() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Options can be removed again.
+	got.Experiment, got.Rules, got.PasswordHash = nil, nil, ""
+	if err := store.Update(got); err != nil {
+		t.Fatal(err)
+	}
+	cleared, err := store.GetByCode(1, "upd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleared.Experiment != nil || len(cleared.Rules) != 0 || cleared.PasswordProtected {
+		t.Errorf("cleared options are still set: %+v", cleared)
+	}
+
+	if err := store.Update(&database.Link{ID: link.ID + 1000, OriginalURL: "https://x.example"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Update() of a missing link = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testList(t *testing.T, store database.LinkStore) {
+	links := []*database.Link{
+		{Code: "l1", DomainID: 1, OriginalURL: "https://1.example", WorkspaceID: 1},
+		{Code: "l2", DomainID: 2, OriginalURL: "https://2.example", WorkspaceID: 1},
+		{Code: "l3", DomainID: 1, OriginalURL: "https://3.example", WorkspaceID: 2},
+		{Code: "l4", DomainID: 1, OriginalURL: "https://4.example"},
+	}
+	for _, l := range links {
+		if err := l.SetDestination(l.OriginalURL, database.UTM{Source: "s" + l.Code[1:], Campaign: "spring"}); err != nil {
+			t.Fatal(err)
+		}
+		create(t, store, l)
+	}
+
+	tests := []struct {
+		filter database.LinkFilter
+		want   []string
+	}{
+		{database.LinkFilter{}, []string{"l4", "l3", "l2", "l1"}},
+		{database.LinkFilter{WorkspaceID: 1}, []string{"l2", "l1"}},
+		{database.LinkFilter{DomainID: 1}, []string{"l4", "l3", "l1"}},
+		{database.LinkFilter{WorkspaceID: 1, DomainID: 2}, []string{"l2"}},
+		{database.LinkFilter{UTMSource: "s3"}, []string{"l3"}},
+		{database.LinkFilter{UTMCampaign: "spring", UTMMedium: "email"}, []string{}},
+		{database.LinkFilter{Limit: 2}, []string{"l4", "l3"}},
+		{database.LinkFilter{Limit: 2, Offset: 3}, []string{"l1"}},
+	}
+
+	for _, tt := range tests {
+		got, err := store.List(tt.filter)
+		if err != nil {
+			t.Fatalf("List(%+v): %v", tt.filter, err)
+		}
+		codes := []string{}
+		for _, l := range got {
+			codes = append(codes, l.Code)
+		}
+		if !slices.Equal(codes, tt.want) {
+			t.Errorf("List(%+v) = %v, want %v", tt.filter, codes, tt.want)
+		}
+	}
+}
+
+func testWorkspaceIDOf(t *testing.T, store database.LinkStore) {
+	link := create(t, store, &database.Link{Code: "ws", DomainID: 1, OriginalURL: "https://example.com", WorkspaceID: 3})
+	anonymous := create(t, store, &database.Link{Code: "anon", DomainID: 1, OriginalURL: "https://example.com"})
+
+	if id, err := store.WorkspaceIDOf(link.ID); err != nil || id != 3 {
+		t.Errorf("WorkspaceIDOf() = %d, %v, want 3", id, err)
+	}
+	if id, err := store.WorkspaceIDOf(anonymous.ID); err != nil || id != 0 {
+		t.Errorf("WorkspaceIDOf() of an anonymous link = %d, %v, want 0", id, err)
+	}
+	if _, err := store.WorkspaceIDOf(link.ID + anonymous.ID + 1000); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("WorkspaceIDOf() of a missing link = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,49 @@
+package storetest
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func testSnippets(t *testing.T, newModels func(t *testing.T) database.Models) {
+	m := newModels(t)
+	store := m.Snippets
+	w := workspace(t, m.Workspaces, "team")
+	other := workspace(t, m.Workspaces, "other")
+
+	pixel := &database.Snippet{WorkspaceID: w.ID, Name: "pixel", Kind: database.SnippetPixel, URL: "https://px.example/p.gif"}
+	inline := &database.Snippet{WorkspaceID: w.ID, Name: "inline", Kind: database.SnippetInline, Code: "track()", Hosts: []string{"t.example"}}
+	for _, s := range []*database.Snippet{pixel, inline} {
+		if err := store.Create(s); err != nil {
+			t.Fatal(err)
+		}
+		if s.ID == 0 || s.CreatedAt.IsZero() {
+			t.Errorf("Create() set %+v", s)
+		}
+	}
+
+	got, err := store.List(w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*database.Snippet{pixel, inline}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+	if got, err := store.List(other.ID); err != nil || len(got) != 0 {
+		t.Errorf("List() of another workspace = %+v, %v", got, err)
+	}
+
+	if err := store.Delete(other.ID, pixel.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Delete() from another workspace = %v, want sql.ErrNoRows", err)
+	}
+	if err := store.Delete(w.ID, pixel.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.List(w.ID); err != nil || len(got) != 1 || got[0].ID != inline.ID {
+		t.Errorf("List() after Delete() = %+v, %v", got, err)
+	}
+}
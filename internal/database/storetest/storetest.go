@@ -0,0 +1,42 @@
+// Package storetest is a conformance suite for implementations of the
+// database stores. It checks the behavior the handlers rely on, so every
+// implementation can be swapped for another.
+package storetest
+
+import (
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// Domains must exist in every set of stores passed to the suite; links are
+// created on them.
+var Domains = []database.Domain{
+	{ID: 1, Host: "short.example", Scheme: "https", Default: true},
+	{ID: 2, Host: "go.example", Scheme: "http"},
+}
+
+// TestModels runs the conformance suite against the stores returned by
+// newModels. It is called for every test and must return empty stores
+// sharing one backend, in which only Domains exist.
+func TestModels(t *testing.T, newModels func(t *testing.T) database.Models) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, newModels func(t *testing.T) database.Models)
+	}{
+		{"Links", testLinks},
+		{"Workspaces", testWorkspaces},
+		{"Domains", testDomains},
+		{"Snippets", testSnippets},
+		{"UTMTemplates", testUTMTemplates},
+		{"Clicks", testClicks},
+		{"Conversions", testConversions},
+		{"Dashboard", testDashboard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newModels)
+		})
+	}
+}
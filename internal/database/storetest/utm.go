@@ -0,0 +1,107 @@
+package storetest
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func testUTMTemplates(t *testing.T, newModels func(t *testing.T) database.Models) {
+	t.Run("Templates", func(t *testing.T) {
+		testTemplates(t, newModels(t))
+	})
+	t.Run("Campaigns", func(t *testing.T) {
+		testCampaigns(t, newModels(t))
+	})
+}
+
+func testTemplates(t *testing.T, m database.Models) {
+	store := m.UTMTemplates
+	w := workspace(t, m.Workspaces, "team")
+	other := workspace(t, m.Workspaces, "other")
+
+	news := &database.UTMTemplate{WorkspaceID: w.ID, Name: "news", UTM: database.UTM{Source: "news", Medium: "email"}}
+	ads := &database.UTMTemplate{WorkspaceID: w.ID, Name: "ads", UTM: database.UTM{Source: "ads", Medium: "cpc", Term: "t"}}
+	for _, tpl := range []*database.UTMTemplate{news, ads} {
+		if err := store.Create(tpl); err != nil {
+			t.Fatal(err)
+		}
+		if tpl.ID == 0 || tpl.CreatedAt.IsZero() {
+			t.Errorf("Create() set %+v", tpl)
+		}
+	}
+
+	// Names are unique per workspace only.
+	if err := store.Create(&database.UTMTemplate{WorkspaceID: w.ID, Name: "news"}); err == nil {
+		t.Error("Create() of a taken name succeeded")
+	}
+	if err := store.Create(&database.UTMTemplate{WorkspaceID: other.ID, Name: "news"}); err != nil {
+		t.Errorf("Create() of a name taken in another workspace = %v", err)
+	}
+
+	if got, err := store.GetByName(w.ID, "news"); err != nil || !reflect.DeepEqual(got, news) {
+		t.Errorf("GetByName() = %+v, %v, want %+v", got, err, news)
+	}
+	if _, err := store.GetByName(w.ID, "none"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByName() of a missing template = %v, want sql.ErrNoRows", err)
+	}
+
+	got, err := store.List(w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*database.UTMTemplate{ads, news}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+
+	if err := store.Delete(other.ID, news.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Delete() from another workspace = %v, want sql.ErrNoRows", err)
+	}
+	if err := store.Delete(w.ID, news.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetByName(w.ID, "news"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByName() after Delete() = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testCampaigns(t *testing.T, m database.Models) {
+	for i, utm := range []database.UTM{
+		{Source: "news", Medium: "email", Campaign: "spring"},
+		{Source: "news", Medium: "email", Campaign: "spring", Content: "footer"},
+		{Source: "ads", Medium: "cpc", Campaign: "spring"},
+		{Source: "news", Medium: "email", Campaign: "autumn"},
+		{},
+	} {
+		link := &database.Link{Code: "c" + string(rune('a'+i)), DomainID: 1, WorkspaceID: 1}
+		if err := link.SetDestination("https://example.com/", utm); err != nil {
+			t.Fatal(err)
+		}
+		create(t, m.Links, link)
+	}
+	other := &database.Link{Code: "other", DomainID: 1, WorkspaceID: 2}
+	if err := other.SetDestination("https://example.com/", database.UTM{Source: "news", Campaign: "spring"}); err != nil {
+		t.Fatal(err)
+	}
+	create(t, m.Links, other)
+
+	got, err := m.UTMTemplates.Campaigns(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []database.CampaignSummary{
+		{Source: "news", Medium: "email", Campaign: "autumn", Links: 1},
+		{Source: "ads", Medium: "cpc", Campaign: "spring", Links: 1},
+		{Source: "news", Medium: "email", Campaign: "spring", Links: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Campaigns() = %+v, want %+v", got, want)
+	}
+
+	if got, err := m.UTMTemplates.Campaigns(9); err != nil || len(got) != 0 {
+		t.Errorf("Campaigns() of a workspace without links = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,49 @@
+package storetest
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// workspace creates a workspace named name and returns it.
+func workspace(t *testing.T, store database.WorkspaceStore, name string) *database.Workspace {
+	t.Helper()
+
+	w := &database.Workspace{Name: name}
+	if _, err := store.Create(w); err != nil {
+		t.Fatalf("Create(%s): %v", name, err)
+	}
+	return w
+}
+
+func testWorkspaces(t *testing.T, newModels func(t *testing.T) database.Models) {
+	store := newModels(t).Workspaces
+
+	w := &database.Workspace{Name: "team"}
+	token, err := store.Create(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.ID == 0 || w.CreatedAt.IsZero() || token == "" {
+		t.Fatalf("Create() = %q, workspace %+v", token, w)
+	}
+	other := workspace(t, store, "other")
+
+	got, err := store.Get(w.ID)
+	if err != nil || got.Name != "team" || !got.CreatedAt.Equal(w.CreatedAt) {
+		t.Errorf("Get() = %+v, %v, want %+v", got, err, w)
+	}
+	if got, err := store.GetByToken(token); err != nil || got.ID != w.ID {
+		t.Errorf("GetByToken() = %+v, %v, want workspace %d", got, err, w.ID)
+	}
+
+	if _, err := store.Get(w.ID + other.ID + 1000); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Get() of a missing workspace = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := store.GetByToken(token + "x"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByToken() of an unknown token = %v, want sql.ErrNoRows", err)
+	}
+}
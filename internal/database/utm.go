@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// UTM holds the campaign parameters appended to a destination URL.
+type UTM struct {
+	Source   string `json:"source,omitempty" validate:"max=100"`
+	Medium   string `json:"medium,omitempty" validate:"max=100"`
+	Campaign string `json:"campaign,omitempty" validate:"max=100"`
+	Term     string `json:"term,omitempty" validate:"max=100"`
+	Content  string `json:"content,omitempty" validate:"max=100"`
+}
+
+// IsZero reports whether no UTM parameter is set.
+func (u UTM) IsZero() bool {
+	return u == UTM{}
+}
+
+// Normalize returns u with values trimmed, lower-cased and spaces replaced
+// by underscores, so "Spring Sale" and "spring_sale " report as one campaign.
+func (u UTM) Normalize() UTM {
+	norm := func(v string) string {
+		return strings.ToLower(strings.Join(strings.Fields(v), "_"))
+	}
+	return UTM{
+		Source:   norm(u.Source),
+		Medium:   norm(u.Medium),
+		Campaign: norm(u.Campaign),
+		Term:     norm(u.Term),
+		Content:  norm(u.Content),
+	}
+}
+
+// Merge returns u with the non-empty values of override applied on top.
+func (u UTM) Merge(override UTM) UTM {
+	pick := func(base, over string) string {
+		if over != "" {
+			return over
+		}
+		return base
+	}
+	return UTM{
+		Source:   pick(u.Source, override.Source),
+		Medium:   pick(u.Medium, override.Medium),
+		Campaign: pick(u.Campaign, override.Campaign),
+		Term:     pick(u.Term, override.Term),
+		Content:  pick(u.Content, override.Content),
+	}
+}
+
+// Apply returns base with the UTM parameters set, replacing any utm_*
+// parameters already present in it.
+func (u UTM) Apply(base string) (string, error) {
+	dst, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	query := dst.Query()
+	for name, value := range map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	dst.RawQuery = query.Encode()
+
+	return dst.String(), nil
+}
+
+// UTMTemplate is a named set of UTM parameters shared by a workspace.
+type UTMTemplate struct {
+	ID          int       `json:"id"`
+	WorkspaceID int       `json:"-"`
+	Name        string    `json:"name"`
+	UTM         UTM       `json:"utm"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UTMTemplateStore persists the UTM templates of workspaces and reports on
+// the campaigns of their links.
+type UTMTemplateStore interface {
+	// Create inserts a template and sets its ID and creation time. Names
+	// are unique per workspace.
+	Create(t *UTMTemplate) error
+	// GetByName returns a template of a workspace by name.
+	// Returns sql.ErrNoRows if it does not exist.
+	GetByName(workspaceID int, name string) (*UTMTemplate, error)
+	// List returns the templates of a workspace ordered by name.
+	List(workspaceID int) ([]*UTMTemplate, error)
+	// Delete removes a template of a workspace.
+	// Returns sql.ErrNoRows if the workspace has no such template.
+	Delete(workspaceID, id int) error
+	// Campaigns counts the links of a workspace carrying UTM values by
+	// campaign, source and medium, in that order.
+	Campaigns(workspaceID int) ([]CampaignSummary, error)
+}
+
+// UTMTemplateModel stores UTM templates in SQLite.
+type UTMTemplateModel struct {
+	DB *sql.DB
+}
+
+var _ UTMTemplateStore = (*UTMTemplateModel)(nil)
+
+// Create inserts a new template.
+func (m *UTMTemplateModel) Create(t *UTMTemplate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO utm_templates (workspace_id, name, source, medium, campaign, term, content)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return m.DB.QueryRowContext(ctx, query, t.WorkspaceID, t.Name, t.UTM.Source, t.UTM.Medium,
+		t.UTM.Campaign, t.UTM.Term, t.UTM.Content).Scan(&t.ID, &t.CreatedAt)
+}
+
+// GetByName retrieves a template of a workspace by name.
+// Returns sql.ErrNoRows if it does not exist.
+func (m UTMTemplateModel) GetByName(workspaceID int, name string) (*UTMTemplate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, workspace_id, name, source, medium, campaign, term, content, created_at
+		FROM utm_templates
+		WHERE workspace_id = $1 AND name = $2
+	`
+
+	var t UTMTemplate
+	err := m.DB.QueryRowContext(ctx, query, workspaceID, name).Scan(&t.ID, &t.WorkspaceID, &t.Name,
+		&t.UTM.Source, &t.UTM.Medium, &t.UTM.Campaign, &t.UTM.Term, &t.UTM.Content, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns all templates of a workspace ordered by name.
+func (m UTMTemplateModel) List(workspaceID int) ([]*UTMTemplate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, workspace_id, name, source, medium, campaign, term, content, created_at
+		FROM utm_templates
+		WHERE workspace_id = $1
+		ORDER BY name
+	`
+	rows, err := m.DB.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*UTMTemplate{}
+	for rows.Next() {
+		var t UTMTemplate
+		err := rows.Scan(&t.ID, &t.WorkspaceID, &t.Name, &t.UTM.Source, &t.UTM.Medium, &t.UTM.Campaign,
+			&t.UTM.Term, &t.UTM.Content, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// Delete removes a template of a workspace.
+// Returns sql.ErrNoRows if it does not exist.
+func (m UTMTemplateModel) Delete(workspaceID, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM utm_templates WHERE workspace_id = $1 AND id = $2`,
+		workspaceID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CampaignSummary counts the links of one source/medium/campaign combination.
+type CampaignSummary struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+	Links    int    `json:"links"`
+}
+
+// Campaigns groups the links of a workspace carrying UTM values by campaign.
+func (m UTMTemplateModel) Campaigns(workspaceID int) ([]CampaignSummary, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT utm_source, utm_medium, utm_campaign, COUNT(*)
+		FROM links
+		WHERE workspace_id = $1 AND base_url != ''
+		GROUP BY utm_campaign, utm_source, utm_medium
+		ORDER BY utm_campaign, utm_source, utm_medium
+	`
+	rows, err := m.DB.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := []CampaignSummary{}
+	for rows.Next() {
+		var c CampaignSummary
+		if err := rows.Scan(&c.Source, &c.Medium, &c.Campaign, &c.Links); err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// ErrInvalidDestination is returned by SetDestination for destinations that
+// are not absolute http(s) URLs. Destinations end up in redirects and in the
+// interstitial page, so javascript: and data: URLs must never be stored.
+var ErrInvalidDestination = errors.New("url must be an absolute http(s) URL")
+
+// SetDestination sets the destination of the link to base with the
+// normalized UTM parameters applied. The unmodified base is only kept when
+// there are UTM values to report on.
+func (l *Link) SetDestination(base string, utm UTM) error {
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidDestination
+	}
+
+	utm = utm.Normalize()
+	if utm.IsZero() {
+		l.OriginalURL, l.BaseURL, l.UTM = base, "", UTM{}
+		l.DestinationHost = destinationHost(u)
+		return nil
+	}
+
+	destination, err := utm.Apply(base)
+	if err != nil {
+		return err
+	}
+	l.OriginalURL, l.BaseURL, l.UTM = destination, base, utm
+	l.DestinationHost = destinationHost(u)
+	return nil
+}
+
+// destinationHost returns the host of u, lowercased and without a port or a
+// leading "www.".
+func destinationHost(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Workspace groups links owned by a team. Members authenticate with the
+// workspace token, which is only stored as a SHA-256 hash.
+type Workspace struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkspaceStore persists workspaces.
+type WorkspaceStore interface {
+	// Create inserts a workspace, sets its ID and creation time and
+	// returns its plaintext access token, which cannot be recovered later.
+	Create(workspace *Workspace) (string, error)
+	// Get returns a workspace by ID.
+	// Returns sql.ErrNoRows if it does not exist.
+	Get(id int) (*Workspace, error)
+	// GetByToken returns the workspace a plaintext access token belongs to.
+	// Returns sql.ErrNoRows if the token is unknown.
+	GetByToken(token string) (*Workspace, error)
+}
+
+// WorkspaceModel stores workspaces in SQLite.
+type WorkspaceModel struct {
+	DB *sql.DB
+}
+
+var _ WorkspaceStore = (*WorkspaceModel)(nil)
+
+// Create inserts a new workspace and returns its plaintext access token.
+// The token cannot be recovered later.
+func (m *WorkspaceModel) Create(workspace *Workspace) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	token := rand.Text()
+	hash := sha256.Sum256([]byte(token))
+
+	query := `
+		INSERT INTO workspaces (name, token_hash)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+	err := m.DB.QueryRowContext(ctx, query, workspace.Name, hash[:]).Scan(&workspace.ID, &workspace.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Get retrieves a workspace by ID.
+// Returns sql.ErrNoRows if it does not exist.
+func (m WorkspaceModel) Get(id int) (*Workspace, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, name, created_at FROM workspaces WHERE id = $1`
+
+	var w Workspace
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(&w.ID, &w.Name, &w.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+// GetByToken retrieves the workspace a plaintext access token belongs to.
+// Returns sql.ErrNoRows if the token is unknown.
+func (m WorkspaceModel) GetByToken(token string) (*Workspace, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	hash := sha256.Sum256([]byte(token))
+	query := `SELECT id, name, created_at FROM workspaces WHERE token_hash = $1`
+
+	var w Workspace
+	err := m.DB.QueryRowContext(ctx, query, hash[:]).Scan(&w.ID, &w.Name, &w.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &w, nil
+}
@@ -0,0 +1,154 @@
+// Package events fans out events to many subscribers, such as clients of a
+// live stream. Publishing never blocks: subscribers that fall behind are
+// disconnected and can resume from a short replay buffer.
+package events
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+var (
+	subscribers = expvar.NewInt("events_subscribers")
+	slow        = expvar.NewInt("events_slow_subscribers")
+)
+
+// Event is a published value with an ID increasing across the events of a
+// hub.
+type Event[T any] struct {
+	ID    uint64
+	Value T
+}
+
+// Hub delivers published events to the subscribers they match.
+type Hub[T any] struct {
+	replaySize int
+	bufferSize int
+
+	mu     sync.Mutex
+	lastID uint64
+	replay []Event[T]
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+}
+
+// New returns a hub that keeps the last replaySize events for resuming
+// subscribers and queues up to bufferSize events per subscriber.
+func New[T any](replaySize, bufferSize int) *Hub[T] {
+	return &Hub[T]{
+		replaySize: replaySize,
+		bufferSize: bufferSize,
+		// IDs start at the current time in microseconds, so they keep
+		// increasing across restarts unless more than a million events
+		// are published per second.
+		lastID: uint64(time.Now().UnixMicro()),
+		subs:   make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Publish assigns the next ID to v and delivers it to the subscribers it
+// matches. Subscribers whose queue is full are disconnected.
+func (h *Hub[T]) Publish(v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.lastID++
+	e := Event[T]{ID: h.lastID, Value: v}
+
+	h.replay = append(h.replay, e)
+	if len(h.replay) > h.replaySize {
+		h.replay = h.replay[len(h.replay)-h.replaySize:]
+	}
+
+	for sub := range h.subs {
+		if !sub.match(v) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			slow.Add(1)
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe returns a subscription to the events match reports true for.
+// Unless lastID is 0, the matching events after lastID that are still in
+// the replay buffer are returned as well; they precede the events of the
+// subscription.
+func (h *Hub[T]) Subscribe(lastID uint64, match func(T) bool) (*Subscription[T], []Event[T]) {
+	sub := &Subscription[T]{
+		hub:    h,
+		match:  match,
+		events: make(chan Event[T], h.bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(sub.events)
+		return sub, nil
+	}
+
+	var missed []Event[T]
+	if lastID != 0 {
+		for _, e := range h.replay {
+			if e.ID > lastID && match(e.Value) {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+	subscribers.Add(1)
+	return sub, missed
+}
+
+// Close disconnects all subscribers and stops accepting new ones.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub)
+	}
+}
+
+// remove disconnects sub. h.mu must be held.
+func (h *Hub[T]) remove(sub *Subscription[T]) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+	subscribers.Add(-1)
+}
+
+// Subscription receives the events of a hub it matches.
+type Subscription[T any] struct {
+	hub    *Hub[T]
+	match  func(T) bool
+	events chan Event[T]
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscriber falls behind, the subscription is closed or the hub is closed.
+func (s *Subscription[T]) Events() <-chan Event[T] {
+	return s.events
+}
+
+// Close ends the subscription.
+func (s *Subscription[T]) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
@@ -0,0 +1,120 @@
+package events
+
+import "testing"
+
+func even(v int) bool { return v%2 == 0 }
+
+func receive(t *testing.T, sub *Subscription[int]) []int {
+	t.Helper()
+	var got []int
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return got
+			}
+			got = append(got, e.Value)
+		default:
+			return got
+		}
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFanOut(t *testing.T) {
+	hub := New[int](10, 10)
+	all, _ := hub.Subscribe(0, func(int) bool { return true })
+	evens, _ := hub.Subscribe(0, even)
+
+	for i := range 5 {
+		hub.Publish(i)
+	}
+
+	if got := receive(t, all); !equal(got, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("subscriber to all events got %v", got)
+	}
+	if got := receive(t, evens); !equal(got, []int{0, 2, 4}) {
+		t.Errorf("subscriber to even events got %v", got)
+	}
+}
+
+func TestResume(t *testing.T) {
+	hub := New[int](4, 10)
+	sub, _ := hub.Subscribe(0, func(int) bool { return true })
+
+	var ids []uint64
+	for i := range 8 {
+		hub.Publish(i)
+		ids = append(ids, (<-sub.Events()).ID)
+	}
+	sub.Close()
+
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("IDs do not increase: %v", ids)
+		}
+	}
+
+	// The buffer holds 4 to 7; of the events after 5, 6 matches.
+	_, missed := hub.Subscribe(ids[5], even)
+	if len(missed) != 1 || missed[0].Value != 6 || missed[0].ID != ids[6] {
+		t.Errorf("missed events after 5 = %v, want 6", missed)
+	}
+
+	// Events older than the buffer are lost.
+	_, missed = hub.Subscribe(ids[0], func(int) bool { return true })
+	if len(missed) != 4 || missed[0].Value != 4 {
+		t.Errorf("missed events after 0 = %v, want 4 to 7", missed)
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	hub := New[int](10, 2)
+	slow, _ := hub.Subscribe(0, func(int) bool { return true })
+	fast, _ := hub.Subscribe(0, func(int) bool { return true })
+
+	for i := range 3 {
+		hub.Publish(i)
+		if i < 2 {
+			<-fast.Events()
+		}
+	}
+
+	if got := receive(t, slow); !equal(got, []int{0, 1}) {
+		t.Errorf("slow subscriber got %v, want its queue before disconnecting", got)
+	}
+	if _, ok := <-slow.Events(); ok {
+		t.Error("slow subscriber was not disconnected")
+	}
+	if got := receive(t, fast); !equal(got, []int{2}) {
+		t.Errorf("fast subscriber got %v, want [2]", got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	hub := New[int](10, 10)
+	sub, _ := hub.Subscribe(0, even)
+	hub.Close()
+
+	if _, ok := <-sub.Events(); ok {
+		t.Error("subscription is open after the hub was closed")
+	}
+	sub.Close() // must not panic
+
+	late, _ := hub.Subscribe(0, even)
+	if _, ok := <-late.Events(); ok {
+		t.Error("subscription to a closed hub is open")
+	}
+	hub.Publish(2) // must not panic
+}
@@ -0,0 +1,277 @@
+// Package export writes raw click events as CSV, NDJSON or Parquet. Clicks
+// are read from the database a page at a time and streamed to the output,
+// so exports of any size run in constant memory.
+//
+// Every export ends at a cursor, the ID of the last click recorded when it
+// started. Passing the cursor to the next export continues after it, so
+// incremental exports neither skip nor repeat clicks.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Formats of exports.
+const (
+	CSV     = "csv"
+	NDJSON  = "ndjson"
+	Parquet = "parquet"
+)
+
+// pageSize is the number of clicks read from the database at a time.
+const pageSize = 1000
+
+// ErrFormat is returned for unknown formats.
+var ErrFormat = errors.New("format must be csv, ndjson or parquet")
+
+// ContentType returns the media type of a format.
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/vnd.apache.parquet"
+}
+
+// Store reads clicks.
+type Store interface {
+	LastID() (int, error)
+	List(filter database.ClickFilter, limit int) ([]*database.Click, error)
+}
+
+// ParseCursor parses a cursor returned by an earlier export. The empty
+// cursor starts at the first click.
+func ParseCursor(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return id, nil
+}
+
+// Export is an export of the clicks matching a filter that were recorded
+// after a cursor and before the export started.
+type Export struct {
+	store  Store
+	filter database.ClickFilter
+
+	// Cursor is where the next export continues.
+	Cursor string
+}
+
+// New prepares an export of the clicks matching filter after cursor.
+func New(store Store, filter database.ClickFilter, cursor string) (*Export, error) {
+	after, err := ParseCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := store.LastID()
+	if err != nil {
+		return nil, err
+	}
+	// Clicks deleted since the cursor was issued may have lowered the
+	// last ID; never move the cursor back.
+	last = max(last, after)
+
+	filter.AfterID, filter.UntilID = after, last
+	return &Export{store: store, filter: filter, Cursor: strconv.Itoa(last)}, nil
+}
+
+// WriteTo writes the clicks to w in format. It returns the number of
+// clicks written.
+func (e *Export) WriteTo(w io.Writer, format string) (int, error) {
+	enc, err := newEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := e.filter
+	var n int
+	for filter.AfterID < filter.UntilID {
+		clicks, err := e.store.List(filter, pageSize)
+		if err != nil {
+			return n, err
+		}
+		for _, c := range clicks {
+			if err := enc.encode(c); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if len(clicks) < pageSize {
+			break
+		}
+		filter.AfterID = clicks[len(clicks)-1].ID
+	}
+
+	return n, enc.close()
+}
+
+// columns are the fields of exported clicks, named like in the API.
+var columns = []struct {
+	name  string
+	value func(c *database.Click) any
+}{
+	{"id", func(c *database.Click) any { return int64(c.ID) }},
+	{"code", func(c *database.Click) any { return c.Code }},
+	{"clicked_at", func(c *database.Click) any { return c.ClickedAt }},
+	{"referrer", func(c *database.Click) any { return c.Referrer }},
+	{"user_agent", func(c *database.Click) any { return c.UserAgent }},
+	{"ip", func(c *database.Click) any { return c.IP }},
+	{"accept_language", func(c *database.Click) any { return c.AcceptLanguage }},
+	{"referrer_host", func(c *database.Click) any { return c.ReferrerHost }},
+	{"country", func(c *database.Click) any { return c.Country }},
+	{"region", func(c *database.Click) any { return c.Region }},
+	{"city", func(c *database.Click) any { return c.City }},
+	{"asn", func(c *database.Click) any { return c.ASN }},
+	{"browser", func(c *database.Click) any { return c.Browser }},
+	{"os", func(c *database.Click) any { return c.OS }},
+	{"device", func(c *database.Click) any { return c.Device }},
+	{"is_bot", func(c *database.Click) any { return c.IsBot }},
+	{"bot", func(c *database.Click) any { return c.Bot }},
+	{"unique", func(c *database.Click) any { return c.Unique }},
+	{"click_id", func(c *database.Click) any { return c.ClickID }},
+	{"experiment_id", func(c *database.Click) any { return c.ExperimentID }},
+	{"variant", func(c *database.Click) any { return c.Variant }},
+}
+
+type encoder interface {
+	encode(c *database.Click) error
+	close() error
+}
+
+func newEncoder(w io.Writer, format string) (encoder, error) {
+	switch format {
+	case CSV:
+		enc := &csvEncoder{w: csv.NewWriter(w)}
+		header := make([]string, len(columns))
+		for i, col := range columns {
+			header[i] = col.name
+		}
+		return enc, enc.w.Write(header)
+	case NDJSON:
+		return ndjsonEncoder{json.NewEncoder(w)}, nil
+	case Parquet:
+		return parquetEncoder{parquet.NewGenericWriter[parquetClick](w, parquet.MaxRowsPerRowGroup(rowGroupSize))}, nil
+	}
+	return nil, ErrFormat
+}
+
+type csvEncoder struct {
+	w   *csv.Writer
+	row []string
+}
+
+func (e *csvEncoder) encode(c *database.Click) error {
+	e.row = e.row[:0]
+	for _, col := range columns {
+		switch v := col.value(c).(type) {
+		case string:
+			e.row = append(e.row, v)
+		case int64:
+			e.row = append(e.row, strconv.FormatInt(v, 10))
+		case bool:
+			e.row = append(e.row, strconv.FormatBool(v))
+		case time.Time:
+			e.row = append(e.row, v.UTC().Format(time.RFC3339Nano))
+		default:
+			return fmt.Errorf("export: column %s has unexpected type %T", col.name, v)
+		}
+	}
+	return e.w.Write(e.row)
+}
+
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e ndjsonEncoder) encode(c *database.Click) error {
+	return e.enc.Encode(c)
+}
+
+func (e ndjsonEncoder) close() error {
+	return nil
+}
+
+// rowGroupSize is the number of clicks per row group of Parquet exports.
+const rowGroupSize = 10000
+
+// parquetClick is a row of Parquet exports, with the columns of CSV exports.
+type parquetClick struct {
+	ID             int64     `parquet:"id"`
+	Code           string    `parquet:"code"`
+	ClickedAt      time.Time `parquet:"clicked_at,timestamp(microsecond)"`
+	Referrer       string    `parquet:"referrer"`
+	UserAgent      string    `parquet:"user_agent"`
+	IP             string    `parquet:"ip"`
+	AcceptLanguage string    `parquet:"accept_language"`
+	ReferrerHost   string    `parquet:"referrer_host"`
+	Country        string    `parquet:"country"`
+	Region         string    `parquet:"region"`
+	City           string    `parquet:"city"`
+	ASN            string    `parquet:"asn"`
+	Browser        string    `parquet:"browser"`
+	OS             string    `parquet:"os"`
+	Device         string    `parquet:"device"`
+	IsBot          bool      `parquet:"is_bot"`
+	Bot            string    `parquet:"bot"`
+	Unique         bool      `parquet:"unique"`
+	ClickID        string    `parquet:"click_id"`
+	ExperimentID   string    `parquet:"experiment_id"`
+	Variant        string    `parquet:"variant"`
+}
+
+type parquetEncoder struct {
+	w *parquet.GenericWriter[parquetClick]
+}
+
+func (e parquetEncoder) encode(c *database.Click) error {
+	_, err := e.w.Write([]parquetClick{{
+		ID:             int64(c.ID),
+		Code:           c.Code,
+		ClickedAt:      c.ClickedAt,
+		Referrer:       c.Referrer,
+		UserAgent:      c.UserAgent,
+		IP:             c.IP,
+		AcceptLanguage: c.AcceptLanguage,
+		ReferrerHost:   c.ReferrerHost,
+		Country:        c.Country,
+		Region:         c.Region,
+		City:           c.City,
+		ASN:            c.ASN,
+		Browser:        c.Browser,
+		OS:             c.OS,
+		Device:         c.Device,
+		IsBot:          c.IsBot,
+		Bot:            c.Bot,
+		Unique:         c.Unique,
+		ClickID:        c.ClickID,
+		ExperimentID:   c.ExperimentID,
+		Variant:        c.Variant,
+	}})
+	return err
+}
+
+func (e parquetEncoder) close() error {
+	return e.w.Close()
+}
@@ -0,0 +1,164 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/parquet-go/parquet-go"
+)
+
+// memStore holds clicks with increasing IDs.
+type memStore struct {
+	clicks []*database.Click
+	pages  int
+}
+
+func (s *memStore) add(n int) {
+	for range n {
+		id := len(s.clicks) + 1
+		s.clicks = append(s.clicks, &database.Click{
+			ID:        id,
+			LinkID:    id%2 + 1,
+			Code:      "abc",
+			ClickedAt: time.Date(2026, 3, 1, 0, 0, id, 0, time.UTC),
+			Referrer:  "https://example.com/a,b",
+		})
+	}
+}
+
+func (s *memStore) LastID() (int, error) {
+	return len(s.clicks), nil
+}
+
+func (s *memStore) List(f database.ClickFilter, limit int) ([]*database.Click, error) {
+	s.pages++
+	var page []*database.Click
+	for _, c := range s.clicks {
+		if c.ID <= f.AfterID || c.ID > f.UntilID || (f.LinkID != 0 && c.LinkID != f.LinkID) {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, c)
+	}
+	return page, nil
+}
+
+func TestIncremental(t *testing.T) {
+	store := &memStore{}
+	store.add(2500)
+
+	e, err := New(store, database.ClickFilter{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := e.WriteTo(&buf, NDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2500 || strings.Count(buf.String(), "\n") != 2500 {
+		t.Errorf("exported %d clicks in %d lines, want 2500", n, strings.Count(buf.String(), "\n"))
+	}
+	if store.pages != 3 {
+		t.Errorf("read %d pages, want 3", store.pages)
+	}
+
+	// Clicks recorded during the export are left to the next one.
+	store.add(10)
+	if e.Cursor != "2500" {
+		t.Fatalf("cursor = %q, want 2500", e.Cursor)
+	}
+
+	next, err := New(store, database.ClickFilter{LinkID: 1}, e.Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if n, err := next.WriteTo(&buf, NDJSON); err != nil || n != 5 {
+		t.Fatalf("incremental export wrote %d clicks (%v), want 5", n, err)
+	}
+	var first database.Click
+	if err := json.Unmarshal([]byte(strings.SplitN(buf.String(), "\n", 2)[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != 2502 {
+		t.Errorf("incremental export starts at click %d, want 2502", first.ID)
+	}
+
+	// Nothing new: the cursor stays.
+	last, _ := New(store, database.ClickFilter{}, "2510")
+	if n, _ := last.WriteTo(&buf, NDJSON); n != 0 || last.Cursor != "2510" {
+		t.Errorf("export without new clicks wrote %d clicks and moved the cursor to %s", n, last.Cursor)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	store := &memStore{}
+	store.add(2)
+
+	e, _ := New(store, database.ClickFilter{}, "")
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf, CSV); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "id" || records[1][0] != "1" {
+		t.Fatalf("unexpected CSV: %v", records)
+	}
+	if got := records[1][2]; got != "2026-03-01T00:00:01Z" {
+		t.Errorf("clicked_at = %q", got)
+	}
+	if got := records[2][3]; got != "https://example.com/a,b" {
+		t.Errorf("referrer = %q", got)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	store := &memStore{}
+	store.add(3)
+
+	e, _ := New(store, database.ClickFilter{}, "")
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf, Parquet); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parquet.Read[parquetClick](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[2].ID != 3 || rows[2].Referrer != "https://example.com/a,b" || !rows[2].ClickedAt.Equal(store.clicks[2].ClickedAt) {
+		t.Errorf("Parquet rows = %+v", rows)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Parquet columns are those of CSV, in the same order.
+	fields := file.Schema().Fields()
+	if len(fields) != len(columns) {
+		t.Fatalf("Parquet export has %d columns, want %d", len(fields), len(columns))
+	}
+	for i, f := range fields {
+		if f.Name() != columns[i].name {
+			t.Errorf("Parquet column %d = %s, want %s", i, f.Name(), columns[i].name)
+		}
+	}
+
+	if _, err := e.WriteTo(&buf, "xml"); err != ErrFormat {
+		t.Errorf("WriteTo() in an unknown format = %v, want ErrFormat", err)
+	}
+	if _, err := New(store, database.ClickFilter{}, "-1"); err == nil {
+		t.Error("New() with an invalid cursor succeeded")
+	}
+}
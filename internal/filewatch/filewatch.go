@@ -0,0 +1,69 @@
+// Package filewatch calls back when files loaded at startup change on disk
+// or the process receives SIGHUP, so they can be reloaded without a restart.
+package filewatch
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch calls reload with the reason when any of files changes or the
+// process receives SIGHUP, until ctx is done. The directories of the files
+// are watched rather than the files themselves, since updates usually
+// replace them (or swap a symlink) instead of writing them in place.
+func Watch(ctx context.Context, files []string, reload func(reason string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		dirs[filepath.Dir(f)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Related files are usually written one after the other; wait for
+	// writes to settle so they are loaded together.
+	settle := time.NewTimer(time.Hour)
+	settle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			reload("SIGHUP")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if dirs[filepath.Dir(event.Name)] {
+				settle.Reset(500 * time.Millisecond)
+			}
+		case <-settle.C:
+			reload("file change")
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("watch %v: %v", files, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package geoip looks up the location and network of client IPs in local
+// MaxMind DB files, such as GeoLite2 or DB-IP Lite, without calling any
+// external service. The files are reloaded when they are replaced.
+package geoip
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/joybiswas007/linkshort/internal/filewatch"
+)
+
+// Location is what the databases know about an IP. Fields are empty when
+// unknown.
+type Location struct {
+	Country string // ISO 3166-1 code, e.g. "DE"
+	Region  string // ISO 3166-2 code when known, e.g. "DE-BE", else the English name
+	City    string // English name
+	ASN     string // e.g. "AS13335 Cloudflare, Inc."
+}
+
+// record holds the fields of the City, Country and ASN databases.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASNumber       uint   `maxminddb:"autonomous_system_number"`
+	ASOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// DB looks IPs up in a set of database files, e.g. a City and an ASN
+// database. A DB without files knows nothing.
+type DB struct {
+	paths []string
+
+	mu      sync.RWMutex
+	readers []*maxminddb.Reader
+}
+
+// Open opens the database files in paths, skipping empty ones.
+func Open(paths ...string) (*DB, error) {
+	db := &DB{}
+	for _, p := range paths {
+		if p != "" {
+			db.paths = append(db.paths, p)
+		}
+	}
+
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Reload opens the database files again. When any of them fails to open the
+// previous ones stay in use.
+func (db *DB) Reload() error {
+	readers := make([]*maxminddb.Reader, 0, len(db.paths))
+	for _, p := range db.paths {
+		var r *maxminddb.Reader
+		// Read the file rather than mapping it, so updates writing it in
+		// place cannot corrupt the reader in use.
+		buf, err := os.ReadFile(p)
+		if err == nil {
+			r, err = maxminddb.FromBytes(buf)
+		}
+		if err != nil {
+			for _, r := range readers {
+				r.Close()
+			}
+			return err
+		}
+		readers = append(readers, r)
+	}
+
+	db.mu.Lock()
+	old := db.readers
+	db.readers = readers
+	db.mu.Unlock()
+
+	for _, r := range old {
+		r.Close()
+	}
+	return nil
+}
+
+// Lookup returns what the databases know about ip.
+func (db *DB) Lookup(ip string) Location {
+	var loc Location
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return loc
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, r := range db.readers {
+		var rec record
+		if err := r.Lookup(addr, &rec); err != nil {
+			continue
+		}
+
+		if loc.Country == "" {
+			loc.Country = rec.Country.ISOCode
+		}
+		if loc.Region == "" && len(rec.Subdivisions) > 0 {
+			sub := rec.Subdivisions[0]
+			switch {
+			case sub.ISOCode != "" && rec.Country.ISOCode != "":
+				loc.Region = rec.Country.ISOCode + "-" + sub.ISOCode
+			default:
+				loc.Region = sub.Names["en"]
+			}
+		}
+		if loc.City == "" {
+			loc.City = rec.City.Names["en"]
+		}
+		if loc.ASN == "" && rec.ASNumber != 0 {
+			loc.ASN = "AS" + strconv.FormatUint(uint64(rec.ASNumber), 10)
+			if rec.ASOrganization != "" {
+				loc.ASN += " " + rec.ASOrganization
+			}
+		}
+	}
+
+	return loc
+}
+
+// Watch reloads the databases when their files change or the process
+// receives SIGHUP, until ctx is done.
+func (db *DB) Watch(ctx context.Context) error {
+	if len(db.paths) == 0 {
+		return nil
+	}
+	return filewatch.Watch(ctx, db.paths, func(reason string) {
+		if err := db.Reload(); err != nil {
+			log.Printf("reload geoip databases on %s: %v", reason, err)
+			return
+		}
+		log.Printf("reloaded geoip databases on %s", reason)
+	})
+}
@@ -0,0 +1,219 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encode appends v in the MaxMind DB data section format. It supports the
+// types the tests need: strings, uint32, maps and arrays shorter than 285.
+func encode(b []byte, v any) []byte {
+	ctrl := func(typ, size int) []byte {
+		// Sizes from 29 to 284 take an extra byte.
+		var ext []byte
+		if size >= 29 {
+			size, ext = 29, []byte{byte(size - 29)}
+		}
+		if typ > 7 {
+			b = append(b, byte(size), byte(typ-7))
+		} else {
+			b = append(b, byte(typ<<5|size))
+		}
+		return append(b, ext...)
+	}
+
+	switch v := v.(type) {
+	case string:
+		b = ctrl(2, len(v))
+		return append(b, v...)
+	case uint32:
+		var buf [4]byte
+		binary.BigEndian.PutUint32(buf[:], v)
+		b = ctrl(6, 4)
+		return append(b, buf[:]...)
+	case map[string]any:
+		b = ctrl(7, len(v))
+		for k, val := range v {
+			b = encode(b, k)
+			b = encode(b, val)
+		}
+		return b
+	case []any:
+		b = ctrl(11, len(v))
+		for _, val := range v {
+			b = encode(b, val)
+		}
+		return b
+	}
+	panic("unsupported type")
+}
+
+// writeDB writes an IPv6 database with record size 24 mapping prefixes to
+// records. IPv4 prefixes are stored under ::/96 like in real databases.
+func writeDB(t *testing.T, path string, records map[string]map[string]any) {
+	t.Helper()
+
+	type rec struct {
+		kind  int // 0 empty, 1 node, 2 data
+		value int
+	}
+	nodes := [][2]rec{{}}
+
+	var data []byte
+	for prefix, record := range records {
+		p := netip.MustParsePrefix(prefix)
+		bits := p.Bits()
+		if p.Addr().Is4() {
+			bits += 96
+		}
+		addr := p.Addr().As16()
+		if p.Addr().Is4() {
+			addr = [16]byte{}
+			copy(addr[12:], p.Addr().AsSlice())
+		}
+
+		offset := len(data)
+		data = encode(data, record)
+
+		node := 0
+		for i := range bits {
+			bit := int(addr[i/8]>>(7-i%8)) & 1
+			if i == bits-1 {
+				nodes[node][bit] = rec{2, offset}
+				break
+			}
+			if nodes[node][bit].kind != 1 {
+				nodes = append(nodes, [2]rec{})
+				nodes[node][bit] = rec{1, len(nodes) - 1}
+			}
+			node = nodes[node][bit].value
+		}
+	}
+
+	count := len(nodes)
+	var file []byte
+	for _, n := range nodes {
+		for _, r := range n {
+			v := count
+			switch r.kind {
+			case 1:
+				v = r.value
+			case 2:
+				v = count + 16 + r.value
+			}
+			file = append(file, byte(v>>16), byte(v>>8), byte(v))
+		}
+	}
+	file = append(file, make([]byte, 16)...)
+	file = append(file, data...)
+	file = append(file, "\xAB\xCD\xEFMaxMind.com"...)
+	file = encode(file, map[string]any{
+		"node_count":                  uint32(count),
+		"record_size":                 uint32(24),
+		"ip_version":                  uint32(6),
+		"database_type":               "Test",
+		"languages":                   []any{"en"},
+		"binary_format_major_version": uint32(2),
+		"binary_format_minor_version": uint32(0),
+		"build_epoch":                 uint32(0),
+		"description":                 map[string]any{},
+	})
+
+	// Replace the file like database updates do.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, file, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func cityRecord(country, region, city string) map[string]any {
+	return map[string]any{
+		"country":      map[string]any{"iso_code": country},
+		"subdivisions": []any{map[string]any{"iso_code": region}},
+		"city":         map[string]any{"names": map[string]any{"en": city}},
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	city := filepath.Join(dir, "city.mmdb")
+	asn := filepath.Join(dir, "asn.mmdb")
+	writeDB(t, city, map[string]map[string]any{
+		"81.2.69.0/24":    cityRecord("DE", "BE", "Berlin"),
+		"2001:db8::/32":   cityRecord("NL", "NH", "Amsterdam"),
+		"203.0.113.0/24":  {"country": map[string]any{"iso_code": "AU"}},
+		"198.51.100.0/24": {"country": map[string]any{"iso_code": "US"}},
+	})
+	writeDB(t, asn, map[string]map[string]any{
+		"81.2.69.0/24": {
+			"autonomous_system_number":       uint32(13335),
+			"autonomous_system_organization": "Cloudflare, Inc.",
+		},
+	})
+
+	db, err := Open(city, "", asn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"81.2.69.142", Location{Country: "DE", Region: "DE-BE", City: "Berlin", ASN: "AS13335 Cloudflare, Inc."}},
+		{"2001:db8::1", Location{Country: "NL", Region: "NL-NH", City: "Amsterdam"}},
+		{"203.0.113.7", Location{Country: "AU"}},
+		{"192.0.2.1", Location{}},
+		{"not-an-ip", Location{}},
+	}
+
+	for _, tt := range tests {
+		if got := db.Lookup(tt.ip); got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, want %+v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeDB(t, path, map[string]map[string]any{"81.2.69.0/24": cityRecord("DE", "BE", "Berlin")})
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeDB(t, path, map[string]map[string]any{"81.2.69.0/24": cityRecord("GB", "ENG", "London")})
+	if err := db.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Lookup("81.2.69.142").City; got != "London" {
+		t.Errorf("Lookup() after reload = %q, want London", got)
+	}
+
+	if err := os.WriteFile(path, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Reload(); err == nil {
+		t.Error("Reload() of a broken database succeeded")
+	}
+	if got := db.Lookup("81.2.69.142").City; got != "London" {
+		t.Errorf("Lookup() after failed reload = %q, want London", got)
+	}
+}
+
+func TestWithoutDatabases(t *testing.T) {
+	db, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Lookup("81.2.69.142"); got != (Location{}) {
+		t.Errorf("Lookup() without databases = %+v, want nothing", got)
+	}
+}
@@ -0,0 +1,176 @@
+// Package hll implements HyperLogLog sketches, which estimate the number of
+// distinct items added to them in a few kilobytes, and can be merged to
+// estimate the distinct items of their union.
+package hll
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// Precision is the default number of index bits. Its 4096 registers give a
+// standard error of about 1.6%.
+const Precision = 12
+
+// Encodings of marshaled sketches.
+const (
+	encodingDense  = 1
+	encodingSparse = 2
+)
+
+var (
+	errPrecision = errors.New("hll: precision must be between 4 and 16")
+	errMismatch  = errors.New("hll: sketches have different precisions")
+	errCorrupt   = errors.New("hll: corrupt sketch")
+)
+
+// Sketch is a HyperLogLog sketch. The zero value is not usable; create
+// sketches with New or UnmarshalBinary.
+type Sketch struct {
+	p         uint8
+	registers []uint8
+}
+
+// New returns an empty sketch with 2^precision registers.
+func New(precision uint8) (*Sketch, error) {
+	if precision < 4 || precision > 16 {
+		return nil, errPrecision
+	}
+	return &Sketch{p: precision, registers: make([]uint8, 1<<precision)}, nil
+}
+
+// Add adds an item to the sketch.
+func (s *Sketch) Add(item []byte) {
+	h := fnv.New64a()
+	h.Write(item)
+	s.AddHash(mix(h.Sum64()))
+}
+
+// AddHash adds an item by its uniformly distributed 64-bit hash.
+func (s *Sketch) AddHash(x uint64) {
+	i := x >> (64 - s.p)
+	// The guard bit caps the rank at 64-p+1 when the remaining bits are 0.
+	rank := uint8(bits.LeadingZeros64(x<<s.p|1<<(s.p-1))) + 1
+	if rank > s.registers[i] {
+		s.registers[i] = rank
+	}
+}
+
+// Merge adds the items of o to the sketch.
+func (s *Sketch) Merge(o *Sketch) error {
+	if s.p != o.p {
+		return errMismatch
+	}
+	for i, r := range o.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate returns the approximate number of distinct items added.
+func (s *Sketch) Estimate() uint64 {
+	m := float64(len(s.registers))
+
+	var sum float64
+	var zeros int
+	for _, r := range s.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(s.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	estimate := alpha * m * m / sum
+
+	// Linear counting is more accurate for small cardinalities.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// MarshalBinary encodes the sketch. Sketches with few items are encoded as
+// a list of their non-empty registers, so rarely clicked links stay small.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	var used int
+	for _, r := range s.registers {
+		if r != 0 {
+			used++
+		}
+	}
+
+	if 3*used >= len(s.registers) {
+		return append([]byte{encodingDense, s.p}, s.registers...), nil
+	}
+
+	b := make([]byte, 2, 2+3*used)
+	b[0], b[1] = encodingSparse, s.p
+	for i, r := range s.registers {
+		if r != 0 {
+			b = append(b, byte(i>>8), byte(i), r)
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary.
+func (s *Sketch) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return errCorrupt
+	}
+	n, err := New(b[1])
+	if err != nil {
+		return err
+	}
+
+	data := b[2:]
+	switch b[0] {
+	case encodingDense:
+		if len(data) != len(n.registers) {
+			return errCorrupt
+		}
+		copy(n.registers, data)
+	case encodingSparse:
+		if len(data)%3 != 0 {
+			return errCorrupt
+		}
+		for ; len(data) > 0; data = data[3:] {
+			i := int(data[0])<<8 | int(data[1])
+			if i >= len(n.registers) {
+				return errCorrupt
+			}
+			n.registers[i] = data[2]
+		}
+	default:
+		return errCorrupt
+	}
+
+	*s = *n
+	return nil
+}
+
+// mix is the finalizer of MurmurHash3, spreading the bits of FNV hashes of
+// similar items over the whole word.
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,92 @@
+package hll
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func fill(t *testing.T, from, to int) *Sketch {
+	t.Helper()
+	s, err := New(Precision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := from; i < to; i++ {
+		s.Add([]byte("visitor-" + strconv.Itoa(i)))
+	}
+	return s
+}
+
+// within reports whether got is within three standard errors of want.
+func within(got uint64, want int) bool {
+	stdErr := 1.04 / math.Sqrt(1<<Precision)
+	return math.Abs(float64(got)-float64(want)) <= 3*stdErr*float64(want)+1
+}
+
+func TestEstimate(t *testing.T) {
+	for _, n := range []int{0, 1, 10, 1000, 20000, 200000} {
+		s := fill(t, 0, n)
+		if got := s.Estimate(); !within(got, n) {
+			t.Errorf("Estimate() of %d items = %d", n, got)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	s := fill(t, 0, 500)
+	for range 3 {
+		for i := range 500 {
+			s.Add([]byte("visitor-" + strconv.Itoa(i)))
+		}
+	}
+	if got := s.Estimate(); !within(got, 500) {
+		t.Errorf("Estimate() of 500 items added 4 times = %d", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	// Three overlapping days: 0-6000, 4000-10000 and 9000-12000.
+	merged := fill(t, 0, 6000)
+	for _, day := range []*Sketch{fill(t, 4000, 10000), fill(t, 9000, 12000)} {
+		if err := merged.Merge(day); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := merged.Estimate(); !within(got, 12000) {
+		t.Errorf("Estimate() of merged sketches = %d, want about 12000", got)
+	}
+
+	other, _ := New(Precision + 1)
+	if err := merged.Merge(other); err == nil {
+		t.Error("Merge() of sketches with different precisions succeeded")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for _, n := range []int{0, 5, 100000} {
+		s := fill(t, 0, n)
+		b, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 5 && len(b) > 2+3*5 {
+			t.Errorf("sketch of 5 items takes %d bytes", len(b))
+		}
+
+		var got Sketch
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatalf("UnmarshalBinary() of %d items: %v", n, err)
+		}
+		if got.Estimate() != s.Estimate() {
+			t.Errorf("round trip of %d items estimates %d, want %d", n, got.Estimate(), s.Estimate())
+		}
+	}
+
+	for _, b := range [][]byte{nil, {encodingDense, Precision, 1}, {encodingSparse, Precision, 0xff, 0xff, 1}, {9, Precision}} {
+		var s Sketch
+		if err := s.UnmarshalBinary(b); err == nil {
+			t.Errorf("UnmarshalBinary(%v) succeeded", b)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package ownership checks that whoever registers a custom domain controls
+// it, either by serving a token over HTTP or by publishing it in DNS.
+package ownership
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// WellKnownPath is where the domain serves its verification token.
+	WellKnownPath = "/.well-known/linkshort-verification"
+	// RecordPrefix is prepended to the host to name the TXT record.
+	RecordPrefix = "_linkshort."
+	// RecordValuePrefix precedes the token in the TXT record value.
+	RecordValuePrefix = "linkshort-verification="
+)
+
+// errHTTPCheck is returned for every failed HTTP check. It does not say
+// why, so the check cannot be used to probe hosts and ports.
+var errHTTPCheck = fmt.Errorf("%s did not serve the verification token", WellKnownPath)
+
+// errForbiddenAddr is returned when dialing an address HTTP checks must not
+// reach.
+var errForbiddenAddr = errors.New("address is not public")
+
+// Verifier checks verification tokens.
+type Verifier struct {
+	resolver *net.Resolver
+	client   *http.Client
+	// allowed reports whether HTTP checks may connect to an address. It is
+	// checked on every dial, so also for every redirect.
+	allowed func(netip.AddrPort) bool
+}
+
+// New returns a verifier that queries the DNS server at resolverAddr, e.g.
+// "1.1.1.1:53", or the system resolver when it is empty.
+func New(resolverAddr string) *Verifier {
+	resolver := net.DefaultResolver
+	if resolverAddr != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, resolverAddr)
+			},
+		}
+	}
+
+	v := &Verifier{resolver: resolver, allowed: publicAddr}
+	dialer := &net.Dialer{
+		Timeout:  5 * time.Second,
+		Resolver: resolver,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil || !v.allowed(netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port())) {
+				return errForbiddenAddr
+			}
+			return nil
+		},
+	}
+	v.client = &http.Client{
+		Timeout: 10 * time.Second,
+		// Connections are not reused, so every request dials and is checked.
+		Transport: &http.Transport{DialContext: dialer.DialContext, DisableKeepAlives: true},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+	return v
+}
+
+// publicAddr reports whether addr may be reached by HTTP checks. Loopback,
+// private, link-local, multicast and unspecified addresses are refused, so
+// workspaces cannot point checks at this instance or its network.
+func publicAddr(ap netip.AddrPort) bool {
+	addr := ap.Addr()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// TXTRecord returns the name and value of the DNS record proving ownership
+// of host with token.
+func TXTRecord(host, token string) (name, value string) {
+	return RecordPrefix + hostname(host), RecordValuePrefix + token
+}
+
+// Verify returns nil when host proves ownership with token through either
+// method, and otherwise an error describing why both failed.
+func (v *Verifier) Verify(ctx context.Context, host, token string) error {
+	dnsErr := v.verifyDNS(ctx, host, token)
+	if dnsErr == nil {
+		return nil
+	}
+	httpErr := v.verifyHTTP(ctx, host, token)
+	if httpErr == nil {
+		return nil
+	}
+	return fmt.Errorf("dns: %v; http: %v", dnsErr, httpErr)
+}
+
+func (v *Verifier) verifyDNS(ctx context.Context, host, token string) error {
+	name, want := TXTRecord(host, token)
+	records, err := v.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(records, want) {
+		return fmt.Errorf("no TXT record %q found on %s", want, name)
+	}
+	return nil
+}
+
+func (v *Verifier) verifyHTTP(ctx context.Context, host, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+WellKnownPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return errHTTPCheck
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errHTTPCheck
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil || strings.TrimSpace(string(body)) != token {
+		return errHTTPCheck
+	}
+	return nil
+}
+
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
@@ -0,0 +1,153 @@
+package ownership
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// serveTXT answers every DNS query on a local UDP socket with a single TXT
+// record holding value, and returns the socket address.
+func serveTXT(t *testing.T, value string) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+
+			// The question follows the 12 byte header: a name, type and class.
+			end := 12
+			for end < n && query[end] != 0 {
+				end += int(query[end]) + 1
+			}
+			end += 5
+			if end > n {
+				continue
+			}
+
+			resp := make([]byte, 0, 512)
+			resp = append(resp, query[0], query[1], 0x81, 0x80, 0, 1, 0, 1, 0, 0, 0, 0)
+			resp = append(resp, query[12:end]...)
+			// Answer: pointer to the question name, TXT, IN, TTL 60.
+			resp = append(resp, 0xc0, 12, 0, 16, 0, 1, 0, 0, 0, 60)
+			resp = binary.BigEndian.AppendUint16(resp, uint16(len(value)+1))
+			resp = append(resp, byte(len(value)))
+			resp = append(resp, value...)
+
+			conn.WriteTo(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestVerifyDNS(t *testing.T) {
+	_, value := TXTRecord("go.example.com", "tok3n")
+	v := New(serveTXT(t, value))
+
+	if err := v.Verify(context.Background(), "go.example.com", "tok3n"); err != nil {
+		t.Errorf("Verify() with matching TXT record = %v", err)
+	}
+	if err := v.Verify(context.Background(), "go.example.com", "other"); err == nil {
+		t.Error("Verify() with a different token succeeded")
+	}
+}
+
+func TestVerifyHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != WellKnownPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("tok3n\n"))
+	}))
+	defer srv.Close()
+
+	// The DNS check fails against a stub publishing an unrelated record.
+	v := New(serveTXT(t, "unrelated"))
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	// The test server listens on loopback, which checks must not reach.
+	if err := v.Verify(context.Background(), host, "tok3n"); err == nil {
+		t.Error("Verify() of a loopback address succeeded")
+	}
+	v.allowed = func(netip.AddrPort) bool { return true }
+
+	if err := v.Verify(context.Background(), host, "tok3n"); err != nil {
+		t.Errorf("Verify() with served token = %v", err)
+	}
+	if err := v.Verify(context.Background(), host, "other"); err == nil {
+		t.Error("Verify() with a different token succeeded")
+	}
+}
+
+func TestVerifyHTTPErrors(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream detail", http.StatusTeapot)
+	}))
+	defer failing.Close()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tok3n"))
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL+WellKnownPath, http.StatusFound))
+	defer redirect.Close()
+
+	v := New(serveTXT(t, "unrelated"))
+	v.allowed = func(netip.AddrPort) bool { return true }
+
+	err := v.verifyHTTP(context.Background(), strings.TrimPrefix(failing.URL, "http://"), "tok3n")
+	if err == nil || strings.Contains(err.Error(), "418") || strings.Contains(err.Error(), "upstream") {
+		t.Errorf("verifyHTTP() of a failing host = %v, want a generic error", err)
+	}
+
+	redirectHost := strings.TrimPrefix(redirect.URL, "http://")
+	if err := v.verifyHTTP(context.Background(), redirectHost, "tok3n"); err != nil {
+		t.Errorf("verifyHTTP() following a redirect = %v", err)
+	}
+
+	// Redirect targets are checked like the host itself.
+	allowed := netip.MustParseAddrPort(redirectHost)
+	v.allowed = func(ap netip.AddrPort) bool { return ap == allowed }
+	if err := v.verifyHTTP(context.Background(), redirectHost, "tok3n"); err == nil {
+		t.Error("verifyHTTP() followed a redirect to a refused address")
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::":              false,
+		"224.0.0.1":       false,
+	} {
+		if got := publicAddr(netip.AddrPortFrom(netip.MustParseAddr(addr), 80)); got != want {
+			t.Errorf("publicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package retention ages click data through coarser tiers. Raw clicks and
+// their minute and hourly rollups are kept for a number of days, daily
+// rollups for a number of months and monthly rollups forever.
+//
+// A background compactor moves expired rollups into the next tier and
+// deletes expired clicks in small batches, each in its own short
+// transaction, so the click writer is never locked out for long.
+package retention
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+)
+
+var (
+	deleted   = expvar.NewInt("retention_clicks_deleted")
+	compacted = expvar.NewInt("retention_rollups_compacted")
+)
+
+// Store deletes expired clicks and compacts expired rollups. Every method
+// handles at most limit rows of a table and returns how many it handled.
+type Store interface {
+	// DeleteClicks deletes clicks recorded before t.
+	DeleteClicks(before time.Time, limit int) (int, error)
+	// CompactHours moves hourly rollups starting before t into daily
+	// rollups, deleting their minute rollups.
+	CompactHours(before time.Time, limit int) (int, error)
+	// CompactDays moves daily rollups starting before t into monthly
+	// rollups.
+	CompactDays(before time.Time, limit int) (int, error)
+}
+
+// Policy sets how long the tiers are kept. Zero values keep a tier forever.
+type Policy struct {
+	RawDays     int // days raw clicks and minute and hourly rollups are kept
+	DailyMonths int // months daily rollups are kept
+}
+
+// Cutoffs returns the times before which raw data and daily rollups expire
+// at now, or zero times when they are kept forever. Raw data expires by
+// whole UTC days and daily rollups by whole UTC months, so every compacted
+// rollup covers a complete day or month.
+func (p Policy) Cutoffs(now time.Time) (raw, daily time.Time) {
+	now = now.UTC()
+	if p.RawDays > 0 {
+		y, m, d := now.Date()
+		raw = time.Date(y, m, d-p.RawDays, 0, 0, 0, 0, time.UTC)
+	}
+	if p.DailyMonths > 0 {
+		y, m, _ := now.Date()
+		daily = time.Date(y, m-time.Month(p.DailyMonths), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return raw, daily
+}
+
+// Options configure a Compactor. Zero values select the defaults.
+type Options struct {
+	BatchSize int           // rows handled per transaction (default 500)
+	Interval  time.Duration // time between compactions (default 1h)
+	Pause     time.Duration // time between batches (default 100ms)
+}
+
+// Compactor periodically applies a Policy to a Store.
+type Compactor struct {
+	store     Store
+	policy    Policy
+	batchSize int
+	interval  time.Duration
+	pause     time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New starts a compactor applying policy to store. It compacts right away
+// and then every interval. Nothing runs when the policy keeps every tier
+// forever.
+func New(store Store, policy Policy, opts Options) *Compactor {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	if opts.Pause <= 0 {
+		opts.Pause = 100 * time.Millisecond
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Compactor{
+		store:     store,
+		policy:    policy,
+		batchSize: opts.BatchSize,
+		interval:  opts.Interval,
+		pause:     opts.Pause,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	if policy == (Policy{}) {
+		close(c.done)
+		return c
+	}
+	go c.run(ctx)
+	return c
+}
+
+// Close stops the compactor and waits until the running batch is done or
+// ctx is done.
+func (c *Compactor) Close(ctx context.Context) error {
+	c.cancel()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Compactor) run(ctx context.Context) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Compact(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("compact clicks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compact applies the policy at now: it deletes expired clicks, then moves
+// expired hourly rollups into daily ones and expired daily rollups into
+// monthly ones. It stops early when ctx is done.
+func (c *Compactor) Compact(ctx context.Context, now time.Time) error {
+	raw, daily := c.policy.Cutoffs(now)
+
+	steps := []struct {
+		before  time.Time
+		do      func(time.Time, int) (int, error)
+		counter *expvar.Int
+	}{
+		{raw, c.store.DeleteClicks, deleted},
+		{raw, c.store.CompactHours, compacted},
+		{daily, c.store.CompactDays, compacted},
+	}
+
+	for _, step := range steps {
+		if step.before.IsZero() {
+			continue
+		}
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			n, err := step.do(step.before, c.batchSize)
+			if err != nil {
+				return err
+			}
+			step.counter.Add(int64(n))
+			if n == 0 {
+				break
+			}
+
+			// Let the click writer in between batches.
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.pause):
+			}
+		}
+	}
+
+	return nil
+}
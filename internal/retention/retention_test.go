@@ -0,0 +1,121 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// memStore holds the number of expired rows per step.
+type memStore struct {
+	clicks, hours, days int
+	calls               []string
+	before              map[string]time.Time
+}
+
+func (s *memStore) take(step string, rows *int, before time.Time, limit int) (int, error) {
+	s.calls = append(s.calls, step)
+	if s.before == nil {
+		s.before = make(map[string]time.Time)
+	}
+	s.before[step] = before
+	n := min(*rows, limit)
+	*rows -= n
+	return n, nil
+}
+
+func (s *memStore) DeleteClicks(before time.Time, limit int) (int, error) {
+	return s.take("clicks", &s.clicks, before, limit)
+}
+
+func (s *memStore) CompactHours(before time.Time, limit int) (int, error) {
+	return s.take("hours", &s.hours, before, limit)
+}
+
+func (s *memStore) CompactDays(before time.Time, limit int) (int, error) {
+	return s.take("days", &s.days, before, limit)
+}
+
+func TestCutoffs(t *testing.T) {
+	now := time.Date(2026, 3, 31, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		policy     Policy
+		raw, daily time.Time
+	}{
+		{Policy{}, time.Time{}, time.Time{}},
+		{Policy{RawDays: 30}, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+		{Policy{DailyMonths: 1}, time.Time{}, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Policy{RawDays: 90, DailyMonths: 13}, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		raw, daily := tt.policy.Cutoffs(now)
+		if !raw.Equal(tt.raw) || !daily.Equal(tt.daily) {
+			t.Errorf("%+v.Cutoffs() = %v, %v, want %v, %v", tt.policy, raw, daily, tt.raw, tt.daily)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	store := &memStore{clicks: 25, hours: 10, days: 3}
+	c := &Compactor{store: store, policy: Policy{RawDays: 7, DailyMonths: 12}, batchSize: 10, pause: time.Millisecond}
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := c.Compact(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"clicks", "clicks", "clicks", "clicks", "hours", "hours", "days", "days"}
+	if len(store.calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", store.calls, want)
+	}
+	for i := range want {
+		if store.calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", store.calls, want)
+		}
+	}
+	if store.clicks+store.hours+store.days != 0 {
+		t.Errorf("rows left after compaction: %+v", store)
+	}
+	if got := store.before["hours"]; !got.Equal(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("hours compacted before %v", got)
+	}
+}
+
+func TestCompactKeepsForever(t *testing.T) {
+	store := &memStore{clicks: 5, hours: 5, days: 5}
+	c := &Compactor{store: store, policy: Policy{DailyMonths: 1}, batchSize: 10, pause: time.Millisecond}
+
+	if err := c.Compact(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if store.clicks != 5 || store.hours != 5 || store.days != 0 {
+		t.Errorf("rows left = %+v, want only raw data", store)
+	}
+
+	// A policy keeping everything never starts.
+	idle := New(store, Policy{}, Options{})
+	if err := idle.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.calls) != 2 {
+		t.Errorf("idle compactor called the store: %v", store.calls)
+	}
+}
+
+func TestCompactCanceled(t *testing.T) {
+	store := &memStore{clicks: 100}
+	c := &Compactor{store: store, policy: Policy{RawDays: 1}, batchSize: 10, pause: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if err := c.Compact(ctx, time.Now()); err != context.Canceled {
+		t.Errorf("Compact() = %v, want context.Canceled", err)
+	}
+	if store.clicks != 90 {
+		t.Errorf("%d clicks left, want 90", store.clicks)
+	}
+}
@@ -0,0 +1,289 @@
+// Package routing evaluates per-link rules that send visitors to different
+// destinations depending on who they are and when they arrive.
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/useragent"
+)
+
+// MaxRules is the maximum number of rules a single link may hold.
+const MaxRules = 50
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Rule sends visitors matching every configured condition to Destination.
+// Each condition matches when the visitor has any of the listed values;
+// conditions left empty match everyone.
+type Rule struct {
+	// Countries are ISO 3166-1 alpha-2 codes, e.g. "US".
+	Countries []string `json:"countries,omitempty"`
+	// OS lists operating systems as reported by the useragent package.
+	OS []string `json:"os,omitempty"`
+	// Devices lists device classes: "mobile", "tablet" or "desktop".
+	Devices []string `json:"devices,omitempty"`
+	// Languages are language tags matched against the visitor's preferred
+	// Accept-Language entry, so "en" matches "en-GB" but "en-US" does not.
+	Languages []string `json:"languages,omitempty"`
+	// Days are abbreviated weekdays: "mon" through "sun".
+	Days []string `json:"days,omitempty"`
+	// Hours limits the rule to a time window; it may wrap past midnight.
+	Hours *HourRange `json:"hours,omitempty"`
+	// Timezone is the IANA zone Days and Hours are evaluated in, UTC if empty.
+	Timezone string `json:"timezone,omitempty"`
+	// Query maps parameter names to the required value; an empty value only
+	// requires the parameter to be present.
+	Query map[string]string `json:"query,omitempty"`
+
+	Destination string `json:"destination"`
+}
+
+// HourRange is the half-open window [From, To) in hours of the day.
+type HourRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// Visitor holds the request attributes rules are evaluated against.
+type Visitor struct {
+	Country  string
+	OS       string
+	Device   string
+	Language string
+	Time     time.Time
+	Query    url.Values
+}
+
+// NewVisitor describes a visitor from raw request attributes.
+func NewVisitor(country, userAgent, acceptLanguage string, t time.Time, query url.Values) Visitor {
+	ua := useragent.Parse(userAgent)
+	return Visitor{
+		Country:  strings.ToUpper(country),
+		OS:       ua.OS,
+		Device:   ua.Device,
+		Language: PreferredLanguage(acceptLanguage),
+		Time:     t,
+		Query:    query,
+	}
+}
+
+// Resolve returns the destination of the first rule matching v together with
+// its index, or fallback and -1 when no rule matches.
+func Resolve(rules []Rule, v Visitor, fallback string) (string, int) {
+	for i := range rules {
+		if rules[i].Matches(v) {
+			return rules[i].Destination, i
+		}
+	}
+	return fallback, -1
+}
+
+// Matches reports whether the visitor satisfies every condition of the rule.
+func (r *Rule) Matches(v Visitor) bool {
+	if len(r.Countries) > 0 && !containsFold(r.Countries, v.Country) {
+		return false
+	}
+	if len(r.OS) > 0 && !containsFold(r.OS, v.OS) {
+		return false
+	}
+	if len(r.Devices) > 0 && !containsFold(r.Devices, v.Device) {
+		return false
+	}
+	if len(r.Languages) > 0 && !slices.ContainsFunc(r.Languages, func(tag string) bool {
+		return languageMatches(tag, v.Language)
+	}) {
+		return false
+	}
+
+	if len(r.Days) > 0 || r.Hours != nil {
+		loc := time.UTC
+		if r.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(r.Timezone); err != nil {
+				return false
+			}
+		}
+		t := v.Time.In(loc)
+
+		if len(r.Days) > 0 && !slices.ContainsFunc(r.Days, func(day string) bool {
+			wd, ok := weekdays[strings.ToLower(day)]
+			return ok && wd == t.Weekday()
+		}) {
+			return false
+		}
+		if r.Hours != nil && !r.Hours.contains(t.Hour()) {
+			return false
+		}
+	}
+
+	for name, want := range r.Query {
+		if !v.Query.Has(name) {
+			return false
+		}
+		if want != "" && v.Query.Get(name) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h HourRange) contains(hour int) bool {
+	if h.From <= h.To {
+		return hour >= h.From && hour < h.To
+	}
+	return hour >= h.From || hour < h.To
+}
+
+// Validate checks that a rule is well formed.
+func (r *Rule) Validate() error {
+	u, err := url.Parse(r.Destination)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("destination must be an absolute http(s) URL")
+	}
+
+	for _, c := range r.Countries {
+		if len(c) != 2 {
+			return fmt.Errorf("country %q must be an ISO 3166-1 alpha-2 code", c)
+		}
+	}
+	for _, os := range r.OS {
+		switch strings.ToLower(os) {
+		case useragent.OSAndroid, useragent.OSIOS, useragent.OSWindows,
+			useragent.OSMacOS, useragent.OSChromeOS, useragent.OSLinux:
+		default:
+			return fmt.Errorf("unknown os %q", os)
+		}
+	}
+	for _, d := range r.Devices {
+		switch strings.ToLower(d) {
+		case useragent.DeviceMobile, useragent.DeviceTablet, useragent.DeviceDesktop:
+		default:
+			return fmt.Errorf("unknown device %q", d)
+		}
+	}
+	for _, tag := range r.Languages {
+		if tag == "" || strings.ContainsAny(tag, " ,;") {
+			return fmt.Errorf("invalid language tag %q", tag)
+		}
+	}
+	for _, day := range r.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("unknown day %q, use mon through sun", day)
+		}
+	}
+	if r.Hours != nil {
+		if r.Hours.From < 0 || r.Hours.From > 23 || r.Hours.To < 0 || r.Hours.To > 24 || r.Hours.From == r.Hours.To {
+			return errors.New("hours must satisfy 0 <= from <= 23, 0 <= to <= 24 and from != to")
+		}
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", r.Timezone)
+		}
+	}
+	for name := range r.Query {
+		if name == "" {
+			return errors.New("query parameter names must not be empty")
+		}
+	}
+	return nil
+}
+
+// ValidationError reports the problems found in a list of rules, keyed by
+// the rule index.
+type ValidationError map[int]string
+
+func (e ValidationError) Error() string {
+	indexes := make([]int, 0, len(e))
+	for i := range e {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	msgs := make([]string, 0, len(e))
+	for _, i := range indexes {
+		msgs = append(msgs, "rule "+strconv.Itoa(i)+": "+e[i])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks every rule and returns a ValidationError describing all
+// invalid ones.
+func Validate(rules []Rule) error {
+	if len(rules) > MaxRules {
+		return fmt.Errorf("a link may have at most %d rules", MaxRules)
+	}
+
+	errs := ValidationError{}
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			errs[i] = err.Error()
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// PreferredLanguage returns the tag with the highest quality in an
+// Accept-Language header, ignoring the "*" wildcard.
+func PreferredLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// languageMatches reports whether a rule tag matches the visitor's tag,
+// treating the rule tag as a prefix on subtag boundaries.
+func languageMatches(rule, visitor string) bool {
+	if visitor == "" {
+		return false
+	}
+	if strings.EqualFold(rule, visitor) {
+		return true
+	}
+	return len(visitor) > len(rule) && visitor[len(rule)] == '-' && strings.EqualFold(rule, visitor[:len(rule)])
+}
+
+func containsFold(values []string, v string) bool {
+	if v == "" {
+		return false
+	}
+	return slices.ContainsFunc(values, func(s string) bool { return strings.EqualFold(s, v) })
+}
@@ -0,0 +1,87 @@
+package routing
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	iphoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+	windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+)
+
+func TestResolve(t *testing.T) {
+	rules := []Rule{
+		{Countries: []string{"DE"}, Languages: []string{"de"}, Destination: "https://example.de"},
+		{OS: []string{"ios"}, Destination: "https://apps.apple.com/app"},
+		{Query: map[string]string{"ref": "mail"}, Destination: "https://example.com/mail"},
+		{Days: []string{"sat", "sun"}, Destination: "https://example.com/weekend"},
+		{Hours: &HourRange{From: 22, To: 6}, Timezone: "Asia/Dhaka", Destination: "https://example.com/night"},
+	}
+
+	monday := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	saturday := time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC)
+	// 23:30 in Dhaka (UTC+6) on a Monday.
+	mondayNightDhaka := time.Date(2026, 10, 19, 17, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		visitor Visitor
+		want    string
+		index   int
+	}{
+		{"country and language", NewVisitor("de", windowsUA, "de-DE,en;q=0.5", monday, nil), "https://example.de", 0},
+		{"country without language", NewVisitor("DE", windowsUA, "en-US", monday, nil), "https://example.com", -1},
+		{"os", NewVisitor("US", iphoneUA, "", monday, nil), "https://apps.apple.com/app", 1},
+		{"query", NewVisitor("", windowsUA, "", monday, url.Values{"ref": {"mail"}}), "https://example.com/mail", 2},
+		{"query value mismatch", NewVisitor("", windowsUA, "", monday, url.Values{"ref": {"web"}}), "https://example.com", -1},
+		{"weekday", NewVisitor("", windowsUA, "", saturday, nil), "https://example.com/weekend", 3},
+		{"hours wrap midnight", NewVisitor("", windowsUA, "", mondayNightDhaka, nil), "https://example.com/night", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, index := Resolve(rules, tt.visitor, "https://example.com")
+			if got != tt.want || index != tt.index {
+				t.Errorf("Resolve() = %q, %d; want %q, %d", got, index, tt.want, tt.index)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []Rule{{Countries: []string{"US"}, Hours: &HourRange{From: 9, To: 17}, Destination: "https://example.com"}}
+	if err := Validate(valid); err != nil {
+		t.Fatalf("Validate(valid) = %v", err)
+	}
+
+	invalid := []Rule{
+		{Destination: "https://example.com"},
+		{Destination: "ftp://example.com"},
+		{Days: []string{"someday"}, Destination: "https://example.com"},
+	}
+	err := Validate(invalid)
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Validate(invalid) = %v, want ValidationError", err)
+	}
+	if len(verr) != 2 || verr[1] == "" || verr[2] == "" {
+		t.Errorf("unexpected validation errors: %v", verr)
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"fr-CH, fr;q=0.9, en;q=0.8": "fr-CH",
+		"en;q=0.5, pt-BR;q=0.9, *":  "pt-BR",
+		"*;q=1, de;q=0.1":           "de",
+		"es;q=invalid, it;q=0.3":    "it",
+	}
+	for header, want := range tests {
+		if got := PreferredLanguage(header); got != want {
+			t.Errorf("PreferredLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
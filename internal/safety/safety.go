@@ -0,0 +1,81 @@
+// Package safety judges whether a link destination looks safe to visit, so
+// visitors can inspect a short URL before following it.
+package safety
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Level is the overall outcome of an evaluation.
+type Level string
+
+const (
+	Safe    Level = "safe"    // no known problems
+	Warning Level = "warning" // reachable, but with traits common to deceptive links
+	Blocked Level = "blocked" // the destination is on the blocklist or unusable
+)
+
+// Verdict is the result of evaluating a destination.
+type Verdict struct {
+	Level   Level    `json:"level"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Engine evaluates destinations against a domain blocklist and a set of
+// heuristics.
+type Engine struct {
+	blocked []string
+}
+
+// New returns an engine rejecting blockedDomains and their subdomains.
+func New(blockedDomains []string) *Engine {
+	e := &Engine{blocked: make([]string, 0, len(blockedDomains))}
+	for _, d := range blockedDomains {
+		if d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), "."); d != "" {
+			e.blocked = append(e.blocked, d)
+		}
+	}
+	return e
+}
+
+// Evaluate returns the verdict for rawURL.
+func (e *Engine) Evaluate(rawURL string) Verdict {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return Verdict{Level: Blocked, Reasons: []string{"the destination is not a valid URL"}}
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	for _, d := range e.blocked {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return Verdict{Level: Blocked, Reasons: []string{"the destination domain is blocklisted"}}
+		}
+	}
+
+	var reasons []string
+	if u.Scheme != "https" {
+		reasons = append(reasons, "the connection to the destination is not encrypted")
+	}
+	if u.User != nil {
+		reasons = append(reasons, "the URL contains a user name, which can disguise the real host")
+	}
+	if net.ParseIP(host) != nil {
+		reasons = append(reasons, "the destination is an IP address rather than a domain name")
+	}
+	for label := range strings.SplitSeq(host, ".") {
+		if strings.HasPrefix(label, "xn--") {
+			reasons = append(reasons, "the domain uses international characters that can imitate another site")
+			break
+		}
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		reasons = append(reasons, "the destination uses the non-standard port "+port)
+	}
+
+	if len(reasons) > 0 {
+		return Verdict{Level: Warning, Reasons: reasons}
+	}
+	return Verdict{Level: Safe}
+}
@@ -0,0 +1,30 @@
+package safety
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	e := New([]string{"Evil.example", "bad.test."})
+
+	tests := []struct {
+		url     string
+		level   Level
+		reasons int
+	}{
+		{"https://example.com/path", Safe, 0},
+		{"https://evil.example/login", Blocked, 1},
+		{"https://login.bad.test", Blocked, 1},
+		{"https://notevil.example", Safe, 0},
+		{"http://example.com", Warning, 1},
+		{"https://paypal.com@203.0.113.7/", Warning, 2},
+		{"https://xn--pypal-4ve.com", Warning, 1},
+		{"http://[2001:db8::1]:8080/", Warning, 3},
+		{"not a url", Blocked, 1},
+	}
+
+	for _, tt := range tests {
+		got := e.Evaluate(tt.url)
+		if got.Level != tt.level || len(got.Reasons) != tt.reasons {
+			t.Errorf("Evaluate(%q) = %+v, want level %s with %d reasons", tt.url, got, tt.level, tt.reasons)
+		}
+	}
+}
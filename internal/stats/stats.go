@@ -0,0 +1,124 @@
+// Package stats groups click rollups into the buckets of a time series in
+// the timezone of the viewer.
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// Granularity is the size of the buckets of a time series.
+type Granularity string
+
+// Granularities supported by time series.
+const (
+	Minute Granularity = "minute"
+	Hour   Granularity = "hour"
+	Day    Granularity = "day"
+	Week   Granularity = "week" // starting on Monday
+)
+
+// ParseGranularity validates a granularity name.
+func ParseGranularity(s string) (Granularity, error) {
+	switch g := Granularity(s); g {
+	case Minute, Hour, Day, Week:
+		return g, nil
+	}
+	return "", fmt.Errorf("granularity must be one of minute, hour, day or week")
+}
+
+// Truncate returns the start of the bucket containing t in loc.
+func (g Granularity) Truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch g {
+	case Minute:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+	case Hour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case Week:
+		// Weekday counts from Sunday, weeks start on Monday.
+		d -= (int(t.Weekday()) + 6) % 7
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// Next returns the start of the bucket following the one starting at t.
+func (g Granularity) Next(t time.Time) time.Time {
+	switch g {
+	case Minute:
+		return t.Add(time.Minute)
+	case Hour:
+		return t.Add(time.Hour)
+	case Week:
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// Align extends [from, to) to the bucket boundaries in loc.
+func (g Granularity) Align(from, to time.Time, loc *time.Location) (time.Time, time.Time) {
+	from = g.Truncate(from, loc)
+	if end := g.Truncate(to, loc); end.Before(to) {
+		to = g.Next(end)
+	} else {
+		to = end
+	}
+	return from, to
+}
+
+// Buckets returns the number of buckets in [from, to), which must be
+// aligned.
+func (g Granularity) Buckets(from, to time.Time) int {
+	switch g {
+	case Minute:
+		return int(to.Sub(from) / time.Minute)
+	case Hour:
+		return int(to.Sub(from) / time.Hour)
+	}
+	n := 0
+	for t := from; t.Before(to); t = g.Next(t) {
+		n++
+	}
+	return n
+}
+
+// Resolution returns the rollup resolution series of g in loc are built
+// from. Hourly rollups only line up with the buckets of zones whose offset
+// is a whole number of hours during [from, to).
+func (g Granularity) Resolution(from, to time.Time, loc *time.Location) time.Duration {
+	if g == Minute {
+		return time.Minute
+	}
+	for _, t := range []time.Time{from, to} {
+		if _, offset := t.In(loc).Zone(); offset%3600 != 0 {
+			return time.Minute
+		}
+	}
+	return time.Hour
+}
+
+// Series groups rollup points into the buckets of g in loc covering the
+// aligned range [from, to). Every bucket is present, including empty ones.
+func Series(points []database.StatPoint, g Granularity, from, to time.Time, loc *time.Location) []database.StatPoint {
+	series := make([]database.StatPoint, 0, g.Buckets(from, to))
+	index := make(map[int64]int)
+	for t := from; t.Before(to); t = g.Next(t) {
+		index[t.Unix()] = len(series)
+		series = append(series, database.StatPoint{Time: t})
+	}
+
+	for _, p := range points {
+		i, ok := index[g.Truncate(p.Time, loc).Unix()]
+		if !ok {
+			continue
+		}
+		series[i].Clicks += p.Clicks
+		series[i].Uniques += p.Uniques
+		series[i].Bots += p.Bots
+	}
+
+	return series
+}
@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestTruncate(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	// Wednesday 2026-03-04 00:30 in Berlin is still Tuesday in UTC.
+	at := time.Date(2026, 3, 3, 23, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		g    Granularity
+		loc  *time.Location
+		want time.Time
+	}{
+		{Minute, time.UTC, time.Date(2026, 3, 3, 23, 30, 0, 0, time.UTC)},
+		{Hour, time.UTC, time.Date(2026, 3, 3, 23, 0, 0, 0, time.UTC)},
+		{Day, time.UTC, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{Day, berlin, time.Date(2026, 3, 4, 0, 0, 0, 0, berlin)},
+		{Week, time.UTC, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{Week, berlin, time.Date(2026, 3, 2, 0, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.g.Truncate(at, tt.loc); !got.Equal(tt.want) {
+			t.Errorf("%s.Truncate() in %s = %v, want %v", tt.g, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestSeries(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	from, to := Day.Align(
+		time.Date(2026, 3, 1, 12, 0, 0, 0, berlin),
+		time.Date(2026, 3, 3, 12, 0, 0, 0, berlin),
+		berlin,
+	)
+
+	points := []database.StatPoint{
+		{Time: time.Date(2026, 2, 28, 23, 0, 0, 0, time.UTC), Clicks: 2, Uniques: 1}, // March 1st in Berlin
+		{Time: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), Clicks: 3, Uniques: 3},
+		{Time: time.Date(2026, 3, 3, 23, 0, 0, 0, time.UTC), Clicks: 7}, // March 4th in Berlin
+	}
+
+	got := Series(points, Day, from, to, berlin)
+
+	want := []int{5, 0, 0}
+	if len(got) != len(want) {
+		t.Fatalf("Series() returned %d buckets, want %d", len(got), len(want))
+	}
+	for i, p := range got {
+		if p.Clicks != want[i] {
+			t.Errorf("bucket %s has %d clicks, want %d", p.Time.Format(time.DateOnly), p.Clicks, want[i])
+		}
+	}
+	if got[0].Uniques != 4 {
+		t.Errorf("first bucket has %d uniques, want 4", got[0].Uniques)
+	}
+}
+
+func TestResolution(t *testing.T) {
+	kolkata := mustLoad(t, "Asia/Kolkata")
+	now := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	if got := Day.Resolution(now, now, time.UTC); got != time.Hour {
+		t.Errorf("Day.Resolution() in UTC = %s, want 1h", got)
+	}
+	if got := Day.Resolution(now, now, kolkata); got != time.Minute {
+		t.Errorf("Day.Resolution() in Asia/Kolkata = %s, want 1m", got)
+	}
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// Trend is a link whose clicks in a recent window outpace its baseline.
+type Trend struct {
+	database.LinkCount
+	// Expected is the number of clicks the baseline rate predicts for the
+	// window.
+	Expected float64 `json:"expected"`
+	// Score is how far the clicks exceed the expected ones, relative to the
+	// noise of a count that large.
+	Score float64 `json:"score"`
+}
+
+// Trending ranks links by the velocity of their clicks: the clicks in a
+// window of the given length compared with the rate of their clicks in the
+// baseline period before it. It returns the n links with the highest score
+// that have more clicks than expected.
+//
+// The excess is divided by the square root of the expected clicks, so a
+// link going from 1,000 to 1,500 clicks a day outranks one going from 1 to
+// 5, and the first clicks of a new link do not put it above busy ones.
+func Trending(counts []database.LinkCount, window, baseline time.Duration, n int) []Trend {
+	trends := []Trend{}
+	for _, c := range counts {
+		expected := float64(c.Baseline) * window.Seconds() / baseline.Seconds()
+		if float64(c.Clicks) <= expected {
+			continue
+		}
+		trends = append(trends, Trend{
+			LinkCount: c,
+			Expected:  math.Round(expected*100) / 100,
+			Score:     math.Round((float64(c.Clicks)-expected)/math.Sqrt(expected+1)*100) / 100,
+		})
+	}
+
+	slices.SortFunc(trends, func(a, b Trend) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), b.Clicks-a.Clicks, strings.Compare(a.ShortURL, b.ShortURL))
+	})
+
+	return trends[:min(n, len(trends))]
+}
@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func TestTrending(t *testing.T) {
+	day, week := 24*time.Hour, 7*24*time.Hour
+
+	counts := []database.LinkCount{
+		{ShortURL: "steady", Clicks: 100, Baseline: 700},  // as usual
+		{ShortURL: "surge", Clicks: 1500, Baseline: 7000}, // 1,000 a day before
+		{ShortURL: "tiny", Clicks: 5, Baseline: 7},        // 1 a day before
+		{ShortURL: "new", Clicks: 3},
+		{ShortURL: "falling", Clicks: 10, Baseline: 700},
+	}
+
+	got := Trending(counts, day, week, 10)
+
+	want := []string{"surge", "new", "tiny"}
+	if len(got) != len(want) {
+		t.Fatalf("Trending() = %+v, want %v", got, want)
+	}
+	for i, trend := range got {
+		if trend.ShortURL != want[i] {
+			t.Errorf("trend %d is %s, want %s", i, trend.ShortURL, want[i])
+		}
+	}
+	if got[0].Expected != 1000 || got[0].Score != 15.8 {
+		t.Errorf("surge expected %v clicks, score %v; want 1000 and 15.8", got[0].Expected, got[0].Score)
+	}
+
+	if got := Trending(counts, day, week, 1); len(got) != 1 || got[0].ShortURL != "surge" {
+		t.Errorf("Trending() with n = 1 = %+v", got)
+	}
+}
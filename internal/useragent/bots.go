@@ -0,0 +1,146 @@
+package useragent
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/joybiswas007/linkshort/internal/filewatch"
+)
+
+// BotEmpty is reported for requests without a User-Agent, which browsers
+// always send.
+const BotEmpty = "empty"
+
+// BotRule classifies user agents matching Pattern as the bot Name.
+type BotRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+//go:embed bots.txt
+var builtinBotRules string
+
+var defaultBotRules = func() []BotRule {
+	rules, err := ParseBotRules(strings.NewReader(builtinBotRules))
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}()
+
+// ParseBotRules reads a rule list. Each line holds a bot name and a regular
+// expression, matched case-insensitively, separated by whitespace. Empty
+// lines and lines starting with '#' are ignored.
+func ParseBotRules(r io.Reader) ([]BotRule, error) {
+	var rules []BotRule
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: want a name and a pattern", n)
+		}
+		name, pattern := line[:i], strings.TrimSpace(line[i:])
+		if pattern == "" {
+			return nil, fmt.Errorf("line %d: want a name and a pattern", n)
+		}
+
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, BotRule{Name: name, Pattern: re})
+	}
+
+	return rules, scanner.Err()
+}
+
+// Bots classifies bots and crawlers by their user agent. Rules read from a
+// file are checked before the built-in ones, so they can add bots or name
+// known ones differently.
+type Bots struct {
+	path string
+
+	mu    sync.RWMutex
+	rules []BotRule
+}
+
+// NewBots returns a classifier using the built-in rules and, unless path is
+// empty, the rules in the file at path.
+func NewBots(path string) (*Bots, error) {
+	b := &Bots{path: path}
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Reload reads the rule file again. When it fails to parse the previous
+// rules stay in use.
+func (b *Bots) Reload() error {
+	var rules []BotRule
+	if b.path != "" {
+		f, err := os.Open(b.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		rules, err = ParseBotRules(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", b.path, err)
+		}
+	}
+	rules = append(rules, defaultBotRules...)
+
+	b.mu.Lock()
+	b.rules = rules
+	b.mu.Unlock()
+	return nil
+}
+
+// Match returns the name of the bot ua belongs to, or an empty string for
+// anything else.
+func (b *Bots) Match(ua string) string {
+	if strings.TrimSpace(ua) == "" {
+		return BotEmpty
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, rule := range b.rules {
+		if rule.Pattern.MatchString(ua) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+// Watch reloads the rule file when it changes or the process receives
+// SIGHUP, until ctx is done.
+func (b *Bots) Watch(ctx context.Context) error {
+	if b.path == "" {
+		return nil
+	}
+	return filewatch.Watch(ctx, []string{b.path}, func(reason string) {
+		if err := b.Reload(); err != nil {
+			log.Printf("reload bot rules on %s: %v", reason, err)
+			return
+		}
+		log.Printf("reloaded bot rules on %s", reason)
+	})
+}
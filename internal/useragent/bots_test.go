@@ -0,0 +1,79 @@
+package useragent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBotsMatch(t *testing.T) {
+	bots, err := NewBots("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ua   string
+		want string
+	}{
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", "slack"},
+		{"Twitterbot/1.0", "twitter"},
+		{"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", "discord"},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", "facebook"},
+		{"Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", "uptimerobot"},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "google"},
+		{"curl/8.5.0", "http-client"},
+		{"Mozilla/5.0 (compatible; SomeNewBot/0.1)", "other"},
+		{"", BotEmpty},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", ""},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36", ""},
+	}
+
+	for _, tt := range tests {
+		if got := bots.Match(tt.ua); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.ua, got, tt.want)
+		}
+	}
+}
+
+func TestBotsRuleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.txt")
+	write := func(rules string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("# our monitoring\nacme-monitor   AcmeCheck/\\d+\nslack-preview  Slackbot\n")
+	bots, err := NewBots(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bots.Match("AcmeCheck/2 (+https://acme.example)"); got != "acme-monitor" {
+		t.Errorf("Match() of a file rule = %q, want acme-monitor", got)
+	}
+	if got := bots.Match("Slackbot-LinkExpanding 1.0"); got != "slack-preview" {
+		t.Errorf("file rules do not take precedence: Match() = %q", got)
+	}
+	if got := bots.Match("Twitterbot/1.0"); got != "twitter" {
+		t.Errorf("built-in rules are not used: Match() = %q", got)
+	}
+
+	write("broken (\n")
+	if err := bots.Reload(); err == nil {
+		t.Error("Reload() of an invalid rule file succeeded")
+	}
+	if got := bots.Match("AcmeCheck/2"); got != "acme-monitor" {
+		t.Errorf("Match() after failed reload = %q, want acme-monitor", got)
+	}
+}
+
+func TestParseBotRules(t *testing.T) {
+	for _, rules := range []string{"lonely-name\n", "name [unclosed\n"} {
+		if _, err := ParseBotRules(strings.NewReader(rules)); err == nil {
+			t.Errorf("ParseBotRules(%q) succeeded", rules)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package useragent classifies HTTP User-Agent strings.
+package useragent
+
+import "strings"
+
+// Operating systems reported by Parse.
+const (
+	OSAndroid  = "android"
+	OSIOS      = "ios"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSChromeOS = "chromeos"
+	OSLinux    = "linux"
+)
+
+// Device classes reported by Parse.
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// Browsers reported by Parse.
+const (
+	BrowserChrome  = "chrome"
+	BrowserEdge    = "edge"
+	BrowserFirefox = "firefox"
+	BrowserOpera   = "opera"
+	BrowserSafari  = "safari"
+	BrowserSamsung = "samsung"
+)
+
+// Info is the classification of a user agent. Fields are empty when unknown.
+type Info struct {
+	OS      string `json:"os,omitempty"`
+	Device  string `json:"device,omitempty"`
+	Browser string `json:"browser,omitempty"`
+}
+
+// Parse classifies the operating system, device class and browser of a
+// user agent.
+func Parse(ua string) Info {
+	if ua == "" {
+		return Info{}
+	}
+
+	var info Info
+	switch {
+	case strings.Contains(ua, "iPad"):
+		info.OS, info.Device = OSIOS, DeviceTablet
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"):
+		info.OS, info.Device = OSIOS, DeviceMobile
+	case strings.Contains(ua, "Android"):
+		info.OS = OSAndroid
+		// Android tablets omit the "Mobile" token.
+		if strings.Contains(ua, "Mobile") {
+			info.Device = DeviceMobile
+		} else {
+			info.Device = DeviceTablet
+		}
+	case strings.Contains(ua, "Windows"):
+		info.OS, info.Device = OSWindows, DeviceDesktop
+	case strings.Contains(ua, "CrOS"):
+		info.OS, info.Device = OSChromeOS, DeviceDesktop
+	case strings.Contains(ua, "Macintosh"), strings.Contains(ua, "Mac OS X"):
+		info.OS, info.Device = OSMacOS, DeviceDesktop
+	case strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"):
+		info.OS, info.Device = OSLinux, DeviceDesktop
+	}
+
+	if info.Device == "" && (strings.Contains(ua, "Mobi") || strings.Contains(ua, "Tablet")) {
+		if strings.Contains(ua, "Tablet") {
+			info.Device = DeviceTablet
+		} else {
+			info.Device = DeviceMobile
+		}
+	}
+
+	info.Browser = browser(ua)
+
+	return info
+}
+
+// browser identifies the browser of a user agent. Most browsers mention the
+// engines they are compatible with, so the more specific tokens are checked
+// first.
+func browser(ua string) string {
+	switch {
+	case strings.Contains(ua, "Edg/"), strings.Contains(ua, "EdgA/"), strings.Contains(ua, "EdgiOS/"):
+		return BrowserEdge
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		return BrowserOpera
+	case strings.Contains(ua, "SamsungBrowser/"):
+		return BrowserSamsung
+	case strings.Contains(ua, "Firefox/"), strings.Contains(ua, "FxiOS/"):
+		return BrowserFirefox
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		return BrowserChrome
+	case strings.Contains(ua, "Safari/") && strings.Contains(ua, "Version/"):
+		return BrowserSafari
+	}
+	return ""
+}
@@ -0,0 +1,3 @@
+ALTER TABLE "links" DROP COLUMN "access_policy";
+ALTER TABLE "links" DROP COLUMN "workspace_id";
+DROP TABLE IF EXISTS "workspaces";
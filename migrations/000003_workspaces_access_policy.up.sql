@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "workspaces" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"name" VARCHAR NOT NULL,
+	"token_hash" BLOB NOT NULL UNIQUE,
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY("id")
+);
+
+ALTER TABLE "links" ADD COLUMN "workspace_id" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "links" ADD COLUMN "access_policy" TEXT NOT NULL DEFAULT '{}';
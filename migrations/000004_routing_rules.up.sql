@@ -0,0 +1 @@
+ALTER TABLE "links" ADD COLUMN "routing_rules" TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "experiment_clicks";
+ALTER TABLE "links" DROP COLUMN "experiment";
@@ -0,0 +1,10 @@
+ALTER TABLE "links" ADD COLUMN "experiment" TEXT;
+
+CREATE TABLE IF NOT EXISTS "experiment_clicks" (
+	"link_id" INTEGER NOT NULL,
+	"experiment_id" VARCHAR NOT NULL,
+	"variant" VARCHAR NOT NULL,
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("link_id", "experiment_id", "variant"),
+	FOREIGN KEY("link_id") REFERENCES "links"("id") ON DELETE CASCADE
+);
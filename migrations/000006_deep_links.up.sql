@@ -0,0 +1 @@
+ALTER TABLE "links" ADD COLUMN "deep_links" TEXT NOT NULL DEFAULT '{}';
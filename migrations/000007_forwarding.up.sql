@@ -0,0 +1 @@
+ALTER TABLE "links" ADD COLUMN "forwarding" TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "links_index_utm";
+ALTER TABLE "links" DROP COLUMN "utm_content";
+ALTER TABLE "links" DROP COLUMN "utm_term";
+ALTER TABLE "links" DROP COLUMN "utm_campaign";
+ALTER TABLE "links" DROP COLUMN "utm_medium";
+ALTER TABLE "links" DROP COLUMN "utm_source";
+ALTER TABLE "links" DROP COLUMN "base_url";
+DROP TABLE IF EXISTS "utm_templates";
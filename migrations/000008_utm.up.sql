@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS "utm_templates" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"workspace_id" INTEGER NOT NULL,
+	"name" VARCHAR NOT NULL,
+	"source" VARCHAR NOT NULL DEFAULT '',
+	"medium" VARCHAR NOT NULL DEFAULT '',
+	"campaign" VARCHAR NOT NULL DEFAULT '',
+	"term" VARCHAR NOT NULL DEFAULT '',
+	"content" VARCHAR NOT NULL DEFAULT '',
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY("id"),
+	UNIQUE("workspace_id", "name"),
+	FOREIGN KEY("workspace_id") REFERENCES "workspaces"("id") ON DELETE CASCADE
+);
+
+ALTER TABLE "links" ADD COLUMN "base_url" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "utm_source" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "utm_medium" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "utm_campaign" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "utm_term" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "utm_content" VARCHAR NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS "links_index_utm"
+ON "links" ("workspace_id", "utm_campaign", "utm_source", "utm_medium");
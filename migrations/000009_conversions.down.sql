@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "conversions";
+ALTER TABLE "links" DROP COLUMN "click_id_param";
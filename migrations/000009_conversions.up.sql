@@ -0,0 +1,20 @@
+ALTER TABLE "links" ADD COLUMN "click_id_param" VARCHAR NOT NULL DEFAULT '';
+
+-- Conversions outlive the clicks they are attributed to, so click IDs are
+-- not foreign keys.
+CREATE TABLE IF NOT EXISTS "conversions" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"click_id" VARCHAR NOT NULL,
+	"link_id" INTEGER NOT NULL,
+	"event" VARCHAR NOT NULL,
+	"value" REAL NOT NULL DEFAULT 0,
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("link_id") REFERENCES "links"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "conversions_index_0"
+ON "conversions" ("link_id", "event");
+
+CREATE INDEX IF NOT EXISTS "conversions_click_id"
+ON "conversions" ("click_id");
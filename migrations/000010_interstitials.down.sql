@@ -0,0 +1,2 @@
+ALTER TABLE "links" DROP COLUMN "interstitial";
+DROP TABLE IF EXISTS "snippets";
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "snippets" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"workspace_id" INTEGER NOT NULL,
+	"name" VARCHAR NOT NULL,
+	"kind" VARCHAR NOT NULL,
+	"url" TEXT NOT NULL DEFAULT '',
+	"code" TEXT NOT NULL DEFAULT '',
+	"hosts" TEXT NOT NULL DEFAULT '[]',
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY("id"),
+	FOREIGN KEY("workspace_id") REFERENCES "workspaces"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "snippets_index_0"
+ON "snippets" ("workspace_id");
+
+ALTER TABLE "links" ADD COLUMN "interstitial" TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE "links" DROP COLUMN "public_stats";
+ALTER TABLE "links" DROP COLUMN "clicks";
@@ -0,0 +1,2 @@
+ALTER TABLE "links" ADD COLUMN "clicks" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "links" ADD COLUMN "public_stats" BOOLEAN NOT NULL DEFAULT 0;
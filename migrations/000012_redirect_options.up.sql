@@ -0,0 +1 @@
+ALTER TABLE "links" ADD COLUMN "redirect_options" TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,52 @@
+CREATE TABLE "links_old" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"code" VARCHAR NOT NULL UNIQUE,
+	"short_url" VARCHAR NOT NULL,
+	"original_url" TEXT NOT NULL,
+	"expires_at" INTEGER NOT NULL DEFAULT 0,
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"password_hash" VARCHAR NOT NULL DEFAULT '',
+	"workspace_id" INTEGER NOT NULL DEFAULT 0,
+	"access_policy" TEXT NOT NULL DEFAULT '{}',
+	"routing_rules" TEXT NOT NULL DEFAULT '[]',
+	"experiment" TEXT,
+	"deep_links" TEXT NOT NULL DEFAULT '{}',
+	"forwarding" TEXT NOT NULL DEFAULT '{}',
+	"base_url" TEXT NOT NULL DEFAULT '',
+	"utm_source" VARCHAR NOT NULL DEFAULT '',
+	"utm_medium" VARCHAR NOT NULL DEFAULT '',
+	"utm_campaign" VARCHAR NOT NULL DEFAULT '',
+	"utm_term" VARCHAR NOT NULL DEFAULT '',
+	"utm_content" VARCHAR NOT NULL DEFAULT '',
+	"click_id_param" VARCHAR NOT NULL DEFAULT '',
+	"interstitial" TEXT NOT NULL DEFAULT '{}',
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"public_stats" BOOLEAN NOT NULL DEFAULT 0,
+	"redirect_options" TEXT NOT NULL DEFAULT '{}',
+	PRIMARY KEY("id")
+);
+
+-- Codes reused on other domains cannot be kept; the default domain wins.
+INSERT OR IGNORE INTO "links_old" ("id", "code", "short_url", "original_url", "expires_at", "created_at",
+	"updated_at", "password_hash", "workspace_id", "access_policy", "routing_rules", "experiment",
+	"deep_links", "forwarding", "base_url", "utm_source", "utm_medium", "utm_campaign", "utm_term",
+	"utm_content", "click_id_param", "interstitial", "clicks", "public_stats", "redirect_options")
+SELECT l."id", l."code", d."scheme" || '://' || d."host" || '/' || l."code", l."original_url",
+	l."expires_at", l."created_at", l."updated_at", l."password_hash", l."workspace_id", l."access_policy",
+	l."routing_rules", l."experiment", l."deep_links", l."forwarding", l."base_url", l."utm_source",
+	l."utm_medium", l."utm_campaign", l."utm_term", l."utm_content", l."click_id_param", l."interstitial",
+	l."clicks", l."public_stats", l."redirect_options"
+FROM "links" l JOIN "domains" d ON d."id" = l."domain_id"
+ORDER BY d."is_default" DESC, l."id";
+
+DROP TABLE "links";
+ALTER TABLE "links_old" RENAME TO "links";
+
+CREATE INDEX IF NOT EXISTS "links_index_0"
+ON "links" ("id", "code");
+
+CREATE INDEX IF NOT EXISTS "links_index_utm"
+ON "links" ("workspace_id", "utm_campaign", "utm_source", "utm_medium");
+
+DROP TABLE IF EXISTS "domains";
@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS "domains" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"host" VARCHAR NOT NULL UNIQUE,
+	"scheme" VARCHAR NOT NULL DEFAULT 'https',
+	"is_default" BOOLEAN NOT NULL DEFAULT 0,
+	"root_redirect" TEXT NOT NULL DEFAULT '',
+	"not_found_url" TEXT NOT NULL DEFAULT '',
+	"link_defaults" TEXT NOT NULL DEFAULT '{}',
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY("id")
+);
+
+-- The configured default domain; its host is set from the configuration at startup.
+INSERT INTO "domains" ("id", "host", "is_default") VALUES (1, '', 1);
+
+-- Codes become unique per domain and short_url is derived from the domain,
+-- which requires rebuilding the links table.
+CREATE TABLE "links_new" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"domain_id" INTEGER NOT NULL DEFAULT 1,
+	"code" VARCHAR NOT NULL,
+	"original_url" TEXT NOT NULL,
+	"expires_at" INTEGER NOT NULL DEFAULT 0,
+	"created_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"updated_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"password_hash" VARCHAR NOT NULL DEFAULT '',
+	"workspace_id" INTEGER NOT NULL DEFAULT 0,
+	"access_policy" TEXT NOT NULL DEFAULT '{}',
+	"routing_rules" TEXT NOT NULL DEFAULT '[]',
+	"experiment" TEXT,
+	"deep_links" TEXT NOT NULL DEFAULT '{}',
+	"forwarding" TEXT NOT NULL DEFAULT '{}',
+	"base_url" TEXT NOT NULL DEFAULT '',
+	"utm_source" VARCHAR NOT NULL DEFAULT '',
+	"utm_medium" VARCHAR NOT NULL DEFAULT '',
+	"utm_campaign" VARCHAR NOT NULL DEFAULT '',
+	"utm_term" VARCHAR NOT NULL DEFAULT '',
+	"utm_content" VARCHAR NOT NULL DEFAULT '',
+	"click_id_param" VARCHAR NOT NULL DEFAULT '',
+	"interstitial" TEXT NOT NULL DEFAULT '{}',
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"public_stats" BOOLEAN NOT NULL DEFAULT 0,
+	"redirect_options" TEXT NOT NULL DEFAULT '{}',
+	PRIMARY KEY("id"),
+	UNIQUE("domain_id", "code"),
+	FOREIGN KEY("domain_id") REFERENCES "domains"("id")
+);
+
+INSERT INTO "links_new" ("id", "code", "original_url", "expires_at", "created_at", "updated_at",
+	"password_hash", "workspace_id", "access_policy", "routing_rules", "experiment", "deep_links",
+	"forwarding", "base_url", "utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+	"click_id_param", "interstitial", "clicks", "public_stats", "redirect_options")
+SELECT "id", "code", "original_url", "expires_at", "created_at", "updated_at",
+	"password_hash", "workspace_id", "access_policy", "routing_rules", "experiment", "deep_links",
+	"forwarding", "base_url", "utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+	"click_id_param", "interstitial", "clicks", "public_stats", "redirect_options"
+FROM "links";
+
+DROP TABLE "links";
+ALTER TABLE "links_new" RENAME TO "links";
+
+CREATE INDEX IF NOT EXISTS "links_index_utm"
+ON "links" ("workspace_id", "utm_campaign", "utm_source", "utm_medium");
@@ -0,0 +1,6 @@
+ALTER TABLE "domains" DROP COLUMN "verification_error";
+ALTER TABLE "domains" DROP COLUMN "failing_since";
+ALTER TABLE "domains" DROP COLUMN "checked_at";
+ALTER TABLE "domains" DROP COLUMN "verified_at";
+ALTER TABLE "domains" DROP COLUMN "verification_token";
+ALTER TABLE "domains" DROP COLUMN "workspace_id";
@@ -0,0 +1,9 @@
+ALTER TABLE "domains" ADD COLUMN "workspace_id" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "domains" ADD COLUMN "verification_token" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "domains" ADD COLUMN "verified_at" TIMESTAMP;
+ALTER TABLE "domains" ADD COLUMN "checked_at" TIMESTAMP;
+ALTER TABLE "domains" ADD COLUMN "failing_since" TIMESTAMP;
+ALTER TABLE "domains" ADD COLUMN "verification_error" TEXT NOT NULL DEFAULT '';
+
+-- Domains added before verification existed were set up by the admin.
+UPDATE "domains" SET "verified_at" = CURRENT_TIMESTAMP;
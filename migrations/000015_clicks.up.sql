@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS "clicks" (
+	"id" INTEGER NOT NULL UNIQUE,
+	"link_id" INTEGER NOT NULL,
+	"code" VARCHAR NOT NULL,
+	"clicked_at" TIMESTAMP NOT NULL,
+	"referrer" TEXT NOT NULL DEFAULT '',
+	"user_agent" TEXT NOT NULL DEFAULT '',
+	-- A daily hash of the client IP when enabled, never the IP itself.
+	"ip" VARCHAR NOT NULL DEFAULT '',
+	"accept_language" VARCHAR NOT NULL DEFAULT '',
+	-- Click IDs are stored on their click, so they expire with it.
+	"click_id" VARCHAR NOT NULL DEFAULT '',
+	-- The experiment variant the visitor was sent to; variant counts are
+	-- added up when clicks are written.
+	"experiment_id" VARCHAR NOT NULL DEFAULT '',
+	"variant" VARCHAR NOT NULL DEFAULT '',
+	PRIMARY KEY("id"),
+	FOREIGN KEY("link_id") REFERENCES "links"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "clicks_index_0"
+ON "clicks" ("link_id", "clicked_at");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "clicks_click_id"
+ON "clicks" ("click_id") WHERE "click_id" != '';
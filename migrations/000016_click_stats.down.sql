@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS "click_stats_dimensions";
+DROP TABLE IF EXISTS "click_stats_hour";
+DROP TABLE IF EXISTS "click_stats_minute";
+DROP TABLE IF EXISTS "link_visitors";
+
+ALTER TABLE "clicks" DROP COLUMN "is_unique";
+ALTER TABLE "clicks" DROP COLUMN "visitor";
+ALTER TABLE "clicks" DROP COLUMN "device";
+ALTER TABLE "clicks" DROP COLUMN "os";
+ALTER TABLE "clicks" DROP COLUMN "browser";
+ALTER TABLE "clicks" DROP COLUMN "country";
+ALTER TABLE "clicks" DROP COLUMN "referrer_host";
@@ -0,0 +1,59 @@
+ALTER TABLE "clicks" ADD COLUMN "referrer_host" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "country" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "browser" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "os" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "device" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "visitor" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "is_unique" BOOLEAN NOT NULL DEFAULT 0;
+
+-- Visitors seen per link; a click is unique when its visitor is new.
+CREATE TABLE IF NOT EXISTS "link_visitors" (
+	"link_id" INTEGER NOT NULL,
+	"visitor" VARCHAR NOT NULL,
+	PRIMARY KEY("link_id", "visitor")
+) WITHOUT ROWID;
+
+-- Rollups keyed by the unix time of the start of their bucket.
+CREATE TABLE IF NOT EXISTS "click_stats_minute" (
+	"link_id" INTEGER NOT NULL,
+	"minute" INTEGER NOT NULL,
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"uniques" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("link_id", "minute")
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS "click_stats_hour" (
+	"link_id" INTEGER NOT NULL,
+	"hour" INTEGER NOT NULL,
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"uniques" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("link_id", "hour")
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS "click_stats_dimensions" (
+	"link_id" INTEGER NOT NULL,
+	"dimension" VARCHAR NOT NULL,
+	"day" INTEGER NOT NULL,
+	"value" VARCHAR NOT NULL,
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"uniques" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("link_id", "dimension", "day", "value")
+) WITHOUT ROWID;
+
+-- Roll up the clicks recorded so far; their dimensions are unknown.
+INSERT INTO "click_stats_minute" ("link_id", "minute", "clicks")
+SELECT "link_id", CAST(strftime('%s', "clicked_at") AS INTEGER) / 60 * 60, COUNT(*)
+FROM "clicks" GROUP BY 1, 2;
+
+INSERT INTO "click_stats_hour" ("link_id", "hour", "clicks")
+SELECT "link_id", CAST(strftime('%s', "clicked_at") AS INTEGER) / 3600 * 3600, COUNT(*)
+FROM "clicks" GROUP BY 1, 2;
+
+INSERT INTO "click_stats_dimensions" ("link_id", "dimension", "day", "value", "clicks")
+SELECT c."link_id", d."name", CAST(strftime('%s', c."clicked_at") AS INTEGER) / 86400 * 86400, '', COUNT(*)
+FROM "clicks" c
+CROSS JOIN (
+	SELECT 'referrer' AS "name" UNION ALL SELECT 'country' UNION ALL SELECT 'browser'
+	UNION ALL SELECT 'os' UNION ALL SELECT 'device'
+) d
+GROUP BY 1, 2, 3;
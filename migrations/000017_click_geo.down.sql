@@ -0,0 +1,5 @@
+DELETE FROM "click_stats_dimensions" WHERE "dimension" IN ('region', 'city', 'asn');
+
+ALTER TABLE "clicks" DROP COLUMN "asn";
+ALTER TABLE "clicks" DROP COLUMN "city";
+ALTER TABLE "clicks" DROP COLUMN "region";
@@ -0,0 +1,3 @@
+ALTER TABLE "clicks" ADD COLUMN "region" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "city" VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE "clicks" ADD COLUMN "asn" VARCHAR NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+DELETE FROM "click_stats_dimensions" WHERE "dimension" = 'bot';
+
+ALTER TABLE "click_stats_dimensions" DROP COLUMN "bots";
+ALTER TABLE "click_stats_hour" DROP COLUMN "bots";
+ALTER TABLE "click_stats_minute" DROP COLUMN "bots";
+
+ALTER TABLE "clicks" DROP COLUMN "bot";
+ALTER TABLE "clicks" DROP COLUMN "is_bot";
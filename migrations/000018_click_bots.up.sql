@@ -0,0 +1,7 @@
+ALTER TABLE "clicks" ADD COLUMN "is_bot" BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE "clicks" ADD COLUMN "bot" VARCHAR NOT NULL DEFAULT '';
+
+-- Rollup clicks and uniques count humans; bots are counted apart.
+ALTER TABLE "click_stats_minute" ADD COLUMN "bots" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "click_stats_hour" ADD COLUMN "bots" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "click_stats_dimensions" ADD COLUMN "bots" INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "click_sketches";
+DROP TABLE IF EXISTS "link_visitors";
+DROP TABLE IF EXISTS "visitor_salts";
+
+CREATE TABLE IF NOT EXISTS "link_visitors" (
+	"link_id" INTEGER NOT NULL,
+	"visitor" VARCHAR NOT NULL,
+	PRIMARY KEY("link_id", "visitor")
+) WITHOUT ROWID;
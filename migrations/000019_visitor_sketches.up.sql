@@ -0,0 +1,27 @@
+-- Secret salts of visitor hashes, one per UTC day, deleted once the day is
+-- over so hashes cannot be traced back to clients or linked across days.
+CREATE TABLE IF NOT EXISTS "visitor_salts" (
+	"day" INTEGER NOT NULL PRIMARY KEY,
+	"salt" BLOB NOT NULL
+);
+
+-- Visitors are now only known per day.
+DROP TABLE IF EXISTS "link_visitors";
+
+CREATE TABLE IF NOT EXISTS "link_visitors" (
+	"link_id" INTEGER NOT NULL,
+	"day" INTEGER NOT NULL,
+	"visitor" VARCHAR NOT NULL,
+	PRIMARY KEY("link_id", "day", "visitor")
+) WITHOUT ROWID;
+
+-- HyperLogLog sketches of the visitors of a link per UTC day.
+CREATE TABLE IF NOT EXISTS "click_sketches" (
+	"link_id" INTEGER NOT NULL,
+	"day" INTEGER NOT NULL,
+	"sketch" BLOB NOT NULL,
+	PRIMARY KEY("link_id", "day")
+) WITHOUT ROWID;
+
+-- Unsalted visitor hashes of earlier clicks could be reversed.
+UPDATE "clicks" SET "visitor" = '' WHERE "visitor" != '';
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS "click_sketches_day";
+DROP INDEX IF EXISTS "click_stats_dimensions_day";
+DROP INDEX IF EXISTS "click_stats_day_day";
+DROP INDEX IF EXISTS "click_stats_hour_hour";
+DROP INDEX IF EXISTS "clicks_clicked_at";
+
+DROP TABLE IF EXISTS "click_sketches_month";
+DROP TABLE IF EXISTS "click_stats_dimensions_month";
+DROP TABLE IF EXISTS "click_stats_month";
+DROP TABLE IF EXISTS "click_stats_day";
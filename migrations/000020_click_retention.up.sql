@@ -0,0 +1,44 @@
+-- Rollups of expired hourly and daily rollups, keyed by the unix time of
+-- the start of their UTC day or month.
+CREATE TABLE IF NOT EXISTS "click_stats_day" (
+	"link_id" INTEGER NOT NULL,
+	"day" INTEGER NOT NULL,
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"uniques" INTEGER NOT NULL DEFAULT 0,
+	"bots" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("link_id", "day")
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS "click_stats_month" (
+	"link_id" INTEGER NOT NULL,
+	"month" INTEGER NOT NULL,
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"uniques" INTEGER NOT NULL DEFAULT 0,
+	"bots" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("link_id", "month")
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS "click_stats_dimensions_month" (
+	"link_id" INTEGER NOT NULL,
+	"dimension" VARCHAR NOT NULL,
+	"month" INTEGER NOT NULL,
+	"value" VARCHAR NOT NULL,
+	"clicks" INTEGER NOT NULL DEFAULT 0,
+	"uniques" INTEGER NOT NULL DEFAULT 0,
+	"bots" INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY("link_id", "dimension", "month", "value")
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS "click_sketches_month" (
+	"link_id" INTEGER NOT NULL,
+	"month" INTEGER NOT NULL,
+	"sketch" BLOB NOT NULL,
+	PRIMARY KEY("link_id", "month")
+) WITHOUT ROWID;
+
+-- Expired rows are found by time across all links.
+CREATE INDEX IF NOT EXISTS "clicks_clicked_at" ON "clicks" ("clicked_at");
+CREATE INDEX IF NOT EXISTS "click_stats_hour_hour" ON "click_stats_hour" ("hour");
+CREATE INDEX IF NOT EXISTS "click_stats_day_day" ON "click_stats_day" ("day");
+CREATE INDEX IF NOT EXISTS "click_stats_dimensions_day" ON "click_stats_dimensions" ("day");
+CREATE INDEX IF NOT EXISTS "click_sketches_day" ON "click_sketches" ("day");
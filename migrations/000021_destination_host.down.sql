@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "links_destination_host";
+ALTER TABLE "links" DROP COLUMN "destination_host";
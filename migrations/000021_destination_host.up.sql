@@ -0,0 +1,24 @@
+-- The host of original_url, lowercased and without a port or a leading
+-- "www.", so destination domains can be grouped in SQL.
+ALTER TABLE "links" ADD COLUMN "destination_host" VARCHAR NOT NULL DEFAULT '';
+
+-- Fill it for existing links by cutting the scheme, path, query, fragment,
+-- user info, port and IPv6 brackets off their destination.
+UPDATE "links" SET "destination_host" = lower(substr("original_url", instr("original_url", '://') + 3))
+WHERE instr("original_url", '://') > 0;
+UPDATE "links" SET "destination_host" = substr("destination_host", 1, instr("destination_host", '/') - 1)
+WHERE instr("destination_host", '/') > 0;
+UPDATE "links" SET "destination_host" = substr("destination_host", 1, instr("destination_host", '?') - 1)
+WHERE instr("destination_host", '?') > 0;
+UPDATE "links" SET "destination_host" = substr("destination_host", 1, instr("destination_host", '#') - 1)
+WHERE instr("destination_host", '#') > 0;
+UPDATE "links" SET "destination_host" = substr("destination_host", instr("destination_host", '@') + 1)
+WHERE instr("destination_host", '@') > 0;
+UPDATE "links" SET "destination_host" = substr("destination_host", 1, instr("destination_host", ':') - 1)
+WHERE instr("destination_host", ':') > 0 AND "destination_host" NOT LIKE '[%';
+UPDATE "links" SET "destination_host" = substr("destination_host", 2, instr("destination_host", ']') - 2)
+WHERE "destination_host" LIKE '[%]%';
+UPDATE "links" SET "destination_host" = substr("destination_host", 5)
+WHERE "destination_host" LIKE 'www.%';
+
+CREATE INDEX IF NOT EXISTS "links_destination_host" ON "links" ("destination_host");
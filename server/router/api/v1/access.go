@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// canAccess reports whether the visitor satisfies the access policy of link.
+// Workspace membership is proven with a workspace token or the signed
+// workspace cookie, the client IP is the one reported by trusted proxies.
+func (s *APIV1Service) canAccess(r *http.Request, link *database.Link) bool {
+	policy := link.AccessPolicy
+	if policy.IsZero() {
+		return true
+	}
+
+	if len(policy.AllowedCIDRs) > 0 && policy.AllowsIP(s.clientIP(r)) {
+		return true
+	}
+
+	if policy.WorkspaceOnly && link.WorkspaceID != 0 {
+		if p := s.contextGetPrincipal(r); p.Workspace != nil && p.Workspace.ID == link.WorkspaceID {
+			return true
+		}
+		if id, ok := s.workspaceFromCookie(r); ok && id == link.WorkspaceID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canManage reports whether the caller may change link: the admin may change
+// any link, workspace members only the links their workspace owns.
+func (s *APIV1Service) canManage(r *http.Request, link *database.Link) bool {
+	p := s.contextGetPrincipal(r)
+	if p.Admin {
+		return true
+	}
+	return p.Workspace != nil && link.WorkspaceID != 0 && p.Workspace.ID == link.WorkspaceID
+}
+
+var errForbidden = errors.New("you do not have permission to manage this link")
+
+// getManagedLink fetches the link for code on the domain the request refers
+// to, on behalf of a caller who must be allowed to manage it. When it fails
+// the error response has already been written and the caller only needs to
+// return.
+func (s *APIV1Service) getManagedLink(w http.ResponseWriter, r *http.Request, code string) (*database.Link, error) {
+	domain, err := s.getAPIDomain(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.db.Links.GetByCode(domain.ID, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.errorResponse(w, http.StatusNotFound, errLinkNotFound.Error())
+			return nil, errLinkNotFound
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return nil, err
+	}
+
+	if !s.canManage(r, link) {
+		s.errorResponse(w, http.StatusForbidden, errForbidden.Error())
+		return nil, errForbidden
+	}
+
+	return link, nil
+}
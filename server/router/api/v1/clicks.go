@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/useragent"
+)
+
+// newClick returns the click event of the redirect served for link. It is
+// queued once the destination is known.
+func (s *APIV1Service) newClick(r *http.Request, link *database.Link) *database.Click {
+	// Country is only known from a CDN header at the time of the request.
+	var country string
+	if s.cfg.CountryHeader != "" {
+		country = strings.ToUpper(truncate(r.Header.Get(s.cfg.CountryHeader), 8))
+	}
+
+	return &database.Click{
+		LinkID:         link.ID,
+		WorkspaceID:    link.WorkspaceID,
+		Code:           link.Code,
+		ClickedAt:      time.Now().UTC(),
+		Referrer:       truncate(r.Referer(), 1024),
+		UserAgent:      truncate(r.UserAgent(), 512),
+		ClientIP:       s.clientIP(r),
+		AcceptLanguage: truncate(r.Header.Get("Accept-Language"), 128),
+		Country:        country,
+	}
+}
+
+// enrichClick derives the statistics dimensions and the visitor of a click
+// and classifies bots. It runs on the click writer. The client IP is only
+// used here; a hash of it keyed with the salt of the day is kept when IPs
+// are hashed.
+// A country reported by the CDN takes precedence over the GeoIP database.
+func (s *APIV1Service) enrichClick(c *database.Click) {
+	geo := s.geoip.Lookup(c.ClientIP)
+	if c.Country == "" {
+		c.Country = geo.Country
+	}
+	c.Region, c.City, c.ASN = geo.Region, geo.City, geo.ASN
+
+	salt, err := s.daySalt(c.ClickedAt)
+	if err != nil {
+		// The click is recorded, but does not count as a unique visit.
+		log.Printf("identify visitor: %v", err)
+	} else {
+		c.Visitor = keyedHash(salt, c.ClientIP+"\x00"+c.UserAgent)
+		if s.cfg.Clicks.HashIPs {
+			c.IP = keyedHash(salt, "click-ip:"+c.ClientIP)
+		}
+	}
+	c.ClientIP = ""
+
+	if u, err := url.Parse(c.Referrer); err == nil {
+		c.ReferrerHost = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	}
+
+	ua := useragent.Parse(c.UserAgent)
+	c.Browser, c.OS, c.Device = ua.Browser, ua.OS, ua.Device
+	c.Bot = s.bots.Match(c.UserAgent)
+	c.IsBot = c.Bot != ""
+}
+
+// visitorSalt caches the salt of the day clicks are recorded on.
+type visitorSalt struct {
+	mu   sync.Mutex
+	day  time.Time
+	salt []byte
+}
+
+// daySalt returns the secret salt of the UTC day of t. Visitors and IPs
+// are hashed with it, so their hashes identify a client within the day
+// only: the salt changes every day and is deleted afterwards, so they can
+// neither be traced back to the client nor linked across days.
+func (s *APIV1Service) daySalt(t time.Time) ([]byte, error) {
+	day := t.UTC().Truncate(24 * time.Hour)
+
+	s.salt.mu.Lock()
+	defer s.salt.mu.Unlock()
+
+	if !s.salt.day.Equal(day) {
+		salt, err := s.db.Clicks.Salt(day)
+		if err != nil {
+			return nil, err
+		}
+		s.salt.day, s.salt.salt = day, salt
+	}
+
+	return s.salt.salt, nil
+}
+
+// keyedHash returns a hash of value keyed with salt.
+func keyedHash(salt []byte, value string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// truncate caps s at n bytes, cutting at a rune boundary.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
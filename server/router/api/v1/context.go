@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+type contextKey string
+
+const principalContextKey = contextKey("principal")
+
+// principal identifies the caller of an authenticated request. The zero
+// value is an anonymous caller.
+type principal struct {
+	Admin     bool
+	Workspace *database.Workspace
+}
+
+// IsAnonymous reports whether the request carried no credentials.
+func (p principal) IsAnonymous() bool {
+	return !p.Admin && p.Workspace == nil
+}
+
+func (s *APIV1Service) contextSetPrincipal(r *http.Request, p principal) *http.Request {
+	ctx := context.WithValue(r.Context(), principalContextKey, p)
+	return r.WithContext(ctx)
+}
+
+func (s *APIV1Service) contextGetPrincipal(r *http.Request) principal {
+	p, _ := r.Context().Value(principalContextKey).(principal)
+	return p
+}
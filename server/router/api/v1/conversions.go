@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// tagClickID issues a click ID for click and returns destination with the
+// ID appended when the link has click IDs enabled. The ID is stored with the
+// click, so conversions can be attributed to it once the click is written.
+func (s *APIV1Service) tagClickID(link *database.Link, click *database.Click, destination string) string {
+	if link.ClickIDParam == "" {
+		return destination
+	}
+
+	clickID, err := s.generateShortCode(20)
+	if err != nil {
+		return destination
+	}
+	tagged, err := withClickID(link, clickID, destination)
+	if err != nil {
+		return destination
+	}
+	click.ClickID = clickID
+
+	return tagged
+}
+
+// withClickID returns destination with clickID in the click ID parameter of
+// link.
+func withClickID(link *database.Link, clickID, destination string) (string, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set(link.ClickIDParam, clickID)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// createConversionHandler attributes a conversion reported by the
+// destination site to the click and link its click ID was issued for.
+// Clicks are written in batches, so a click ID is only known once the
+// recorder has flushed its click, at most the flush interval after the
+// redirect.
+func (s *APIV1Service) createConversionHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		ClickID string  `json:"click_id" validate:"required,max=64"`
+		Event   string  `json:"event" validate:"required,max=64"`
+		Value   float64 `json:"value"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	linkID, err := s.db.Conversions.LinkIDForClick(input.ClickID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.errorResponse(w, http.StatusNotFound, "unknown click_id")
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Workspaces may only report conversions for their own links.
+	if p := s.contextGetPrincipal(r); !p.Admin {
+		owner, err := s.db.Links.WorkspaceIDOf(linkID)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if owner == 0 || owner != p.Workspace.ID {
+			s.errorResponse(w, http.StatusNotFound, "unknown click_id")
+			return
+		}
+	}
+
+	conversion := &database.Conversion{
+		ClickID: input.ClickID,
+		LinkID:  linkID,
+		Event:   input.Event,
+		Value:   input.Value,
+	}
+
+	err = s.db.Conversions.Create(conversion)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusCreated, conversion)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) linkConversionsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	summary, err := s.db.Conversions.Summary(link.ID)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, summary)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
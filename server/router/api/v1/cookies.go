@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+const (
+	// unlockCookieTTL is how long a correct link password stays valid.
+	unlockCookieTTL = time.Hour
+	// workspaceCookieTTL is how long a browser stays signed in to a workspace.
+	workspaceCookieTTL = 7 * 24 * time.Hour
+
+	workspaceCookieName = "ls_workspace"
+)
+
+// signValue returns payload followed by an HMAC of the payload and binding.
+// The binding is not stored in the result, so a value signed against one
+// binding fails verification once the binding changes.
+func (s *APIV1Service) signValue(payload, binding string) string {
+	return payload + "." + s.mac(payload, binding)
+}
+
+// verifyValue checks a value produced by signValue and returns its payload.
+func (s *APIV1Service) verifyValue(signed, binding string) (string, bool) {
+	idx := strings.LastIndexByte(signed, '.')
+	if idx < 0 {
+		return "", false
+	}
+	payload, sig := signed[:idx], signed[idx+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.mac(payload, binding))) {
+		return "", false
+	}
+	return payload, true
+}
+
+func (s *APIV1Service) mac(payload, binding string) string {
+	h := hmac.New(sha256.New, s.cookieKey)
+	h.Write([]byte(payload))
+	h.Write([]byte{0})
+	h.Write([]byte(binding))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// setSignedCookie issues a cookie carrying subject, signed against binding
+// and valid for ttl.
+func (s *APIV1Service) setSignedCookie(w http.ResponseWriter, name, subject, binding string, ttl time.Duration) {
+	expires := time.Now().Add(ttl)
+	payload := subject + "|" + strconv.FormatInt(expires.Unix(), 10)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    s.signValue(payload, binding),
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   s.cfg.IsProduction,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// readSignedCookie returns the subject of a valid, unexpired cookie issued
+// by setSignedCookie.
+func (s *APIV1Service) readSignedCookie(r *http.Request, name, binding string) (string, bool) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+
+	payload, ok := s.verifyValue(cookie.Value, binding)
+	if !ok {
+		return "", false
+	}
+
+	subject, exp, ok := strings.Cut(payload, "|")
+	if !ok {
+		return "", false
+	}
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() >= expUnix {
+		return "", false
+	}
+	return subject, true
+}
+
+func (s *APIV1Service) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.cfg.IsProduction,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func unlockCookieName(code string) string {
+	return "ls_unlock_" + code
+}
+
+// setUnlockCookie issues a short-lived cookie proving the visitor entered the
+// correct password for link. It is bound to the current password hash, so
+// changing the password revokes every issued cookie.
+func (s *APIV1Service) setUnlockCookie(w http.ResponseWriter, link *database.Link) {
+	s.setSignedCookie(w, unlockCookieName(link.Code), link.Code, link.PasswordHash, unlockCookieTTL)
+}
+
+// isUnlocked reports whether the request may access link without a password.
+func (s *APIV1Service) isUnlocked(r *http.Request, link *database.Link) bool {
+	if !link.PasswordProtected {
+		return true
+	}
+
+	code, ok := s.readSignedCookie(r, unlockCookieName(link.Code), link.PasswordHash)
+	return ok && code == link.Code
+}
+
+// setWorkspaceCookie signs the browser in as a member of workspace.
+func (s *APIV1Service) setWorkspaceCookie(w http.ResponseWriter, workspace *database.Workspace) {
+	s.setSignedCookie(w, workspaceCookieName, strconv.Itoa(workspace.ID), workspaceCookieName, workspaceCookieTTL)
+}
+
+// workspaceFromCookie returns the workspace ID the browser is signed in to.
+func (s *APIV1Service) workspaceFromCookie(r *http.Request) (int, bool) {
+	subject, ok := s.readSignedCookie(r, workspaceCookieName, workspaceCookieName)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/stats"
+)
+
+const (
+	// dashboardTTL is how long a dashboard is served from the cache.
+	dashboardTTL = 30 * time.Second
+
+	// Trending links are ranked by their clicks in the last trendWindow
+	// compared with the trendBaseline before it.
+	trendWindow   = 24 * time.Hour
+	trendBaseline = 7 * 24 * time.Hour
+)
+
+// dashboard is the response of the admin dashboard endpoint.
+type dashboard struct {
+	GeneratedAt     time.Time              `json:"generated_at"`
+	From            time.Time              `json:"from"`
+	To              time.Time              `json:"to"`
+	Totals          *database.Totals       `json:"totals"`
+	LinksPerDay     []database.DayCount    `json:"links_per_day"`
+	ClicksPerDay    []database.StatPoint   `json:"clicks_per_day"`
+	TopLinks        []database.LinkCount   `json:"top_links"`
+	Trending        []stats.Trend          `json:"trending"`
+	TopDestinations []database.DomainCount `json:"top_destinations"`
+}
+
+type dashboardKey struct {
+	days, top int
+}
+
+// dashboardCache keeps dashboards briefly, so reloading the admin UI does
+// not query the database every time. Dashboards are built while holding
+// mu, so concurrent requests wait for one build instead of each running
+// their own.
+type dashboardCache struct {
+	mu      sync.Mutex
+	entries map[dashboardKey]*dashboard
+}
+
+// dashboardHandler reports what is happening on the instance: totals, links
+// created and clicks per UTC day over the last days (default 30), the top
+// links by clicks over those days, trending links, the top destination
+// domains and the links expiring soon. Trending links have more clicks in
+// the last 24 hours than their rate over the 7 days before predicts.
+//
+// Results are cached for 30 seconds.
+func (s *APIV1Service) dashboardHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	qs := r.URL.Query()
+
+	key := dashboardKey{days: 30, top: 10}
+	if v := qs.Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 || days > 90 {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "days must be between 1 and 90")
+			return
+		}
+		key.days = days
+	}
+	if v := qs.Get("top"); v != "" {
+		top, err := strconv.Atoi(v)
+		if err != nil || top < 1 || top > 100 {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "top must be between 1 and 100")
+			return
+		}
+		key.top = top
+	}
+
+	s.dashboards.mu.Lock()
+	d := s.dashboards.entries[key]
+	if d == nil || time.Since(d.GeneratedAt) > dashboardTTL {
+		var err error
+		d, err = s.buildDashboard(key, time.Now().UTC())
+		if err != nil {
+			s.dashboards.mu.Unlock()
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if s.dashboards.entries == nil {
+			s.dashboards.entries = make(map[dashboardKey]*dashboard)
+		}
+		s.dashboards.entries[key] = d
+	}
+	s.dashboards.mu.Unlock()
+
+	err := s.writeJSON(w, http.StatusOK, map[string]any{"dashboard": d})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// buildDashboard queries the dashboard covering the UTC day of now and the
+// days before it.
+func (s *APIV1Service) buildDashboard(key dashboardKey, now time.Time) (*dashboard, error) {
+	to := stats.Day.Next(stats.Day.Truncate(now, time.UTC))
+	from := to.AddDate(0, 0, -key.days)
+
+	d := &dashboard{GeneratedAt: now, From: from, To: to}
+
+	var err error
+	if d.Totals, err = s.db.Dashboard.Totals(now); err != nil {
+		return nil, err
+	}
+
+	links, err := s.db.Dashboard.LinksPerDay(from, to)
+	if err != nil {
+		return nil, err
+	}
+	d.LinksPerDay = make([]database.DayCount, 0, key.days)
+	for t := from; t.Before(to); t = stats.Day.Next(t) {
+		c := database.DayCount{Time: t}
+		if len(links) > 0 && links[0].Time.Equal(t) {
+			c.Count, links = links[0].Count, links[1:]
+		}
+		d.LinksPerDay = append(d.LinksPerDay, c)
+	}
+
+	clicks, err := s.db.Dashboard.ClicksPerDay(from, to)
+	if err != nil {
+		return nil, err
+	}
+	d.ClicksPerDay = stats.Series(clicks, stats.Day, from, to, time.UTC)
+
+	if d.TopLinks, err = s.db.Dashboard.TopLinks(from, to, key.top); err != nil {
+		return nil, err
+	}
+
+	// The window ends with the current hour.
+	end := now.Truncate(time.Hour).Add(time.Hour)
+	since := end.Add(-trendWindow)
+	velocity, err := s.db.Dashboard.LinkVelocity(since.Add(-trendBaseline), since, end)
+	if err != nil {
+		return nil, err
+	}
+	d.Trending = stats.Trending(velocity, trendWindow, trendBaseline, key.top)
+
+	if d.TopDestinations, err = s.db.Dashboard.TopDestinations(key.top); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/useragent"
+)
+
+// serveDeepLink sends mobile visitors into the app configured for their
+// platform, reporting whether it wrote a response. Universal and app links
+// are plain redirects, since the OS opens the app or falls back to the
+// website itself. Custom schemes cannot fall back on their own: Android gets
+// an intent URL with a browser fallback and iOS a page that tries the app
+// before continuing to the store or web URL.
+//
+// The path suffix and query of the request are forwarded to the app URL as
+// they are to webURL, and the app URL carries the same click ID, if any.
+func (s *APIV1Service) serveDeepLink(w http.ResponseWriter, r *http.Request, link *database.Link, webURL, suffix, clickID string) bool {
+	var target *database.AppTarget
+	platform := useragent.Parse(r.UserAgent()).OS
+	switch platform {
+	case useragent.OSIOS:
+		target = link.DeepLinks.IOS
+	case useragent.OSAndroid:
+		target = link.DeepLinks.Android
+	}
+	if target == nil {
+		return false
+	}
+
+	appURL, err := link.Forwarding.Apply(target.URL, suffix, r.URL.Query())
+	if err != nil {
+		return false
+	}
+	if clickID != "" {
+		if appURL, err = withClickID(link, clickID, appURL); err != nil {
+			return false
+		}
+	}
+
+	w.Header().Add("Vary", "User-Agent")
+
+	if !target.IsCustomScheme() {
+		http.Redirect(w, r, appURL, http.StatusFound)
+		return true
+	}
+
+	fallback := target.StoreURL
+	if fallback == "" {
+		fallback = webURL
+	}
+
+	if platform == useragent.OSAndroid {
+		http.Redirect(w, r, androidIntentURL(appURL, fallback), http.StatusFound)
+		return true
+	}
+
+	nonce, err := s.generateShortCode(22)
+	if err != nil {
+		return false
+	}
+	s.renderPageWithCSP(w, http.StatusOK, "open_app.html", map[string]any{
+		"Code": link.Code,
+		// AppTarget validation rejects script-capable schemes.
+		"AppURL":      template.URL(appURL),
+		"AppURLJS":    appURL,
+		"FallbackURL": fallback,
+		"Nonce":       nonce,
+	}, "default-src 'none'; style-src 'unsafe-inline'; script-src 'nonce-"+nonce+"'; frame-ancestors 'none'")
+	return true
+}
+
+// androidIntentURL converts a custom scheme URL into an intent URL, which
+// Chrome opens in the app or, when it is not installed, at fallback.
+func androidIntentURL(appURL, fallback string) string {
+	scheme, rest, _ := strings.Cut(appURL, "://")
+	return "intent://" + rest + "#Intent;scheme=" + scheme +
+		";S.browser_fallback_url=" + url.QueryEscape(fallback) + ";end"
+}
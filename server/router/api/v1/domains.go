@@ -0,0 +1,455 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/ownership"
+)
+
+var (
+	errUnknownDomain    = errors.New("unknown domain")
+	errUnverifiedDomain = errors.New("domain ownership has not been verified")
+	errDomainForbidden  = errors.New("you do not have permission to manage this domain")
+)
+
+// refuseUnverifiedHosts answers requests for registered domains whose
+// ownership is not verified with 421 Misdirected Request. The verification
+// token under /.well-known stays reachable so the domain can prove itself.
+func (s *APIV1Service) refuseUnverifiedHosts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		domain, err := s.db.Domains.GetByHost(r.Host)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		case !domain.Verified():
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				s.errorResponse(w, http.StatusMisdirectedRequest, errUnverifiedDomain.Error())
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusMisdirectedRequest), http.StatusMisdirectedRequest)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hostDomain returns the domain serving r. Hosts that are not registered
+// are served by the default domain.
+func (s *APIV1Service) hostDomain(r *http.Request) (*database.Domain, error) {
+	domain, err := s.db.Domains.GetByHost(r.Host)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.db.Domains.Default()
+	}
+	return domain, err
+}
+
+// domainByHost returns the registered domain for host, or errUnknownDomain.
+func (s *APIV1Service) domainByHost(host string) (*database.Domain, error) {
+	domain, err := s.db.Domains.GetByHost(host)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errUnknownDomain
+	}
+	return domain, err
+}
+
+// getAPIDomain returns the domain whose links an API request refers to: the
+// one named by the domain query parameter, or else the one serving the
+// request. When it fails the error response has already been written.
+func (s *APIV1Service) getAPIDomain(w http.ResponseWriter, r *http.Request) (*database.Domain, error) {
+	var domain *database.Domain
+	var err error
+	if host := r.URL.Query().Get("domain"); host != "" {
+		domain, err = s.domainByHost(host)
+	} else {
+		domain, err = s.hostDomain(r)
+	}
+	if err != nil {
+		if errors.Is(err, errUnknownDomain) {
+			s.errorResponse(w, http.StatusNotFound, err.Error())
+			return nil, err
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return nil, err
+	}
+	return domain, nil
+}
+
+// rootHandler sends visitors of a bare domain to its root redirect, and
+// reports whether it did.
+func (s *APIV1Service) rootHandler(w http.ResponseWriter, r *http.Request) bool {
+	domain, err := s.hostDomain(r)
+	if err != nil {
+		log.Printf("root %q: %v", r.Host, err)
+		return false
+	}
+	if domain.RootRedirect == "" {
+		return false
+	}
+	http.Redirect(w, r, domain.RootRedirect, http.StatusFound)
+	return true
+}
+
+// redirectNotFound sends visitors of an unknown or expired code to the 404
+// destination of the domain, and reports whether it did.
+func redirectNotFound(w http.ResponseWriter, r *http.Request, domain *database.Domain) bool {
+	if domain.NotFoundURL == "" {
+		return false
+	}
+	http.Redirect(w, r, domain.NotFoundURL, http.StatusFound)
+	return true
+}
+
+// canManageDomain reports whether the caller may change domain: the admin
+// may change any domain, workspace members only the ones they added.
+func (s *APIV1Service) canManageDomain(r *http.Request, domain *database.Domain) bool {
+	p := s.contextGetPrincipal(r)
+	if p.Admin {
+		return true
+	}
+	return p.Workspace != nil && domain.WorkspaceID != 0 && p.Workspace.ID == domain.WorkspaceID
+}
+
+// canUseDomain reports whether the caller may create links on domain:
+// domains without a workspace are shared, the others belong to the
+// workspace that added them.
+func (s *APIV1Service) canUseDomain(r *http.Request, domain *database.Domain) bool {
+	return domain.WorkspaceID == 0 || s.canManageDomain(r, domain)
+}
+
+// getManagedDomain fetches the domain for host on behalf of a caller who
+// must be allowed to manage it. When it fails the error response has already
+// been written.
+func (s *APIV1Service) getManagedDomain(w http.ResponseWriter, r *http.Request, host string) (*database.Domain, error) {
+	domain, err := s.domainByHost(host)
+	if err != nil {
+		if errors.Is(err, errUnknownDomain) {
+			s.errorResponse(w, http.StatusNotFound, err.Error())
+			return nil, err
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return nil, err
+	}
+
+	// Hide the domains of other workspaces.
+	if !s.canManageDomain(r, domain) {
+		if domain.WorkspaceID != 0 {
+			s.errorResponse(w, http.StatusNotFound, errUnknownDomain.Error())
+			return nil, errUnknownDomain
+		}
+		s.errorResponse(w, http.StatusForbidden, errDomainForbidden.Error())
+		return nil, errDomainForbidden
+	}
+
+	return domain, nil
+}
+
+// domainResponse is a domain together with the instructions for proving
+// its ownership, for domains that still have to.
+type domainResponse struct {
+	*database.Domain
+	Verification *verificationInstructions `json:"verification,omitempty"`
+}
+
+// verificationInstructions tell a workspace how to prove ownership: by
+// serving the token at HTTPURL from its own server, or by publishing the TXT
+// record. Once the host points at this instance only the TXT record can
+// pass the periodic checks.
+type verificationInstructions struct {
+	HTTPURL  string `json:"http_url"`
+	TXTName  string `json:"txt_name"`
+	TXTValue string `json:"txt_value"`
+}
+
+func newDomainResponse(d *database.Domain) domainResponse {
+	resp := domainResponse{Domain: d}
+	if d.VerificationToken != "" {
+		name, value := ownership.TXTRecord(d.Host, d.VerificationToken)
+		resp.Verification = &verificationInstructions{
+			HTTPURL:  "http://" + d.Host + ownership.WellKnownPath,
+			TXTName:  name,
+			TXTValue: value,
+		}
+	}
+	return resp
+}
+
+type domainInput struct {
+	RootRedirect *string                `json:"root_redirect"`
+	NotFoundURL  *string                `json:"not_found_url"`
+	LinkDefaults *database.LinkDefaults `json:"link_defaults"`
+	// WorkspaceID assigns the domain to a workspace, 0 shares it with
+	// everyone. Only the admin may set it.
+	WorkspaceID *int `json:"workspace_id" validate:"omitnil,min=0"`
+}
+
+func (in domainInput) apply(d *database.Domain) {
+	if in.RootRedirect != nil {
+		d.RootRedirect = *in.RootRedirect
+	}
+	if in.NotFoundURL != nil {
+		d.NotFoundURL = *in.NotFoundURL
+	}
+	if in.LinkDefaults != nil {
+		d.LinkDefaults = *in.LinkDefaults
+	}
+	if in.WorkspaceID != nil {
+		d.WorkspaceID = *in.WorkspaceID
+	}
+}
+
+// listDomainsHandler lists every domain to the admin, and the shared
+// domains and its own to a workspace.
+func (s *APIV1Service) listDomainsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	domains, err := s.db.Domains.List()
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]domainResponse, 0, len(domains))
+	for _, d := range domains {
+		if s.canUseDomain(r, d) {
+			resp = append(resp, newDomainResponse(d))
+		}
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"domains": resp})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// createDomainHandler adds a domain. Domains added by the admin serve links
+// right away; a workspace has to prove ownership of its domains first,
+// following the verification instructions in the response.
+func (s *APIV1Service) createDomainHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Host   string `json:"host" validate:"required"`
+		Scheme string `json:"scheme" validate:"omitempty,oneof=http https"`
+		domainInput
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	p := s.contextGetPrincipal(r)
+	if input.WorkspaceID != nil && !p.Admin {
+		s.errorResponse(w, http.StatusForbidden, "only the admin can assign domains to workspaces")
+		return
+	}
+
+	domain := &database.Domain{
+		Host:   database.NormalizeHost(input.Host),
+		Scheme: input.Scheme,
+	}
+	if domain.Scheme == "" {
+		domain.Scheme = "https"
+	}
+	input.apply(domain)
+
+	if p.Admin {
+		now := time.Now().UTC()
+		domain.VerifiedAt = &now
+	} else {
+		domain.WorkspaceID = p.Workspace.ID
+		domain.VerificationToken = rand.Text()
+	}
+
+	if err := domain.Validate(); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = s.db.Domains.Create(domain)
+	if err != nil {
+		if isUniqueViolation(err) {
+			s.errorResponse(w, http.StatusConflict, "domain already exists")
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusCreated, newDomainResponse(domain))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) updateDomainHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	var input domainInput
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	if input.WorkspaceID != nil && !s.contextGetPrincipal(r).Admin {
+		s.errorResponse(w, http.StatusForbidden, "only the admin can assign domains to workspaces")
+		return
+	}
+
+	domain, err := s.getManagedDomain(w, r, params.ByName("host"))
+	if err != nil {
+		return
+	}
+
+	if input.WorkspaceID != nil && domain.Default && *input.WorkspaceID != 0 {
+		s.errorResponse(w, http.StatusConflict, "the default domain cannot be assigned to a workspace")
+		return
+	}
+
+	input.apply(domain)
+
+	if err := domain.Validate(); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = s.db.Domains.Update(domain)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, newDomainResponse(domain))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// verifyDomainHandler checks the ownership of a domain right away instead of
+// waiting for the next periodic check.
+func (s *APIV1Service) verifyDomainHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	domain, err := s.getManagedDomain(w, r, params.ByName("host"))
+	if err != nil {
+		return
+	}
+
+	if domain.VerificationToken == "" {
+		s.errorResponse(w, http.StatusConflict, "domain does not need to be verified")
+		return
+	}
+
+	err = s.checkDomain(r.Context(), domain)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, newDomainResponse(domain))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) deleteDomainHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	domain, err := s.getManagedDomain(w, r, params.ByName("host"))
+	if err != nil {
+		return
+	}
+
+	if domain.Default {
+		s.errorResponse(w, http.StatusConflict, "the default domain cannot be deleted")
+		return
+	}
+
+	err = s.db.Domains.Delete(domain.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrDomainInUse):
+			s.errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			s.errorResponse(w, http.StatusNotFound, errUnknownDomain.Error())
+		default:
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkDomain verifies the ownership of domain and saves the outcome.
+func (s *APIV1Service) checkDomain(ctx context.Context, domain *database.Domain) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	err := s.verifier.Verify(ctx, domain.Host, domain.VerificationToken)
+	domain.RecordCheck(err, time.Now().UTC(), s.domainGracePeriod())
+	return s.db.Domains.UpdateVerification(domain)
+}
+
+// verifyDomains periodically checks the ownership of domains added by
+// workspaces. Verified domains are checked every configured interval,
+// pending and failing ones every few minutes.
+func (s *APIV1Service) verifyDomains() {
+	interval := s.cfg.DomainVerification.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	for {
+		time.Sleep(time.Minute)
+
+		domains, err := s.db.Domains.List()
+		if err != nil {
+			log.Printf("verify domains: %v", err)
+			continue
+		}
+
+		now := time.Now().UTC()
+		for _, d := range domains {
+			if d.VerificationToken == "" || !d.DueForCheck(now, interval, 5*time.Minute) {
+				continue
+			}
+			if err := s.checkDomain(context.Background(), d); err != nil {
+				log.Printf("verify domain %q: %v", d.Host, err)
+			}
+		}
+	}
+}
+
+func (s *APIV1Service) domainGracePeriod() time.Duration {
+	if grace := s.cfg.DomainVerification.GracePeriod; grace > 0 {
+		return grace
+	}
+	return 72 * time.Hour
+}
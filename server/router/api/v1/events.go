@@ -0,0 +1,217 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/events"
+)
+
+const (
+	// clickReplaySize is how many recent clicks streams can resume from.
+	clickReplaySize = 1000
+	// clickQueueSize is how many clicks may wait for a stream client before
+	// it is disconnected.
+	clickQueueSize = 256
+	// heartbeatInterval keeps idle streams from being closed by proxies.
+	heartbeatInterval = 15 * time.Second
+	// streamWriteTimeout bounds every write to a stream client, in place of
+	// the server's WriteTimeout, which would end streams after 30 seconds.
+	streamWriteTimeout = 10 * time.Second
+)
+
+// clickEvent is a click as pushed to stream clients, without the IP, user
+// agent, full referrer and other personal data.
+type clickEvent struct {
+	Code         string    `json:"code"`
+	ClickedAt    time.Time `json:"clicked_at"`
+	ReferrerHost string    `json:"referrer_host,omitempty"`
+	Country      string    `json:"country,omitempty"`
+	Region       string    `json:"region,omitempty"`
+	City         string    `json:"city,omitempty"`
+	ASN          string    `json:"asn,omitempty"`
+	Browser      string    `json:"browser,omitempty"`
+	OS           string    `json:"os,omitempty"`
+	Device       string    `json:"device,omitempty"`
+	IsBot        bool      `json:"is_bot"`
+	Bot          string    `json:"bot,omitempty"`
+	Unique       bool      `json:"unique"`
+}
+
+// streamedClick is a published click, encoded once for all subscribers.
+type streamedClick struct {
+	linkID      int
+	workspaceID int
+	bot         bool
+	data        []byte
+}
+
+// publishClicks pushes stored clicks to the stream clients. It runs on the
+// click writer.
+func (s *APIV1Service) publishClicks(clicks []*database.Click) {
+	for _, c := range clicks {
+		data, err := json.Marshal(clickEvent{
+			Code:         c.Code,
+			ClickedAt:    c.ClickedAt,
+			ReferrerHost: c.ReferrerHost,
+			Country:      c.Country,
+			Region:       c.Region,
+			City:         c.City,
+			ASN:          c.ASN,
+			Browser:      c.Browser,
+			OS:           c.OS,
+			Device:       c.Device,
+			IsBot:        c.IsBot,
+			Bot:          c.Bot,
+			Unique:       c.Unique,
+		})
+		if err != nil {
+			log.Printf("encode click event: %v", err)
+			continue
+		}
+		s.events.Publish(streamedClick{
+			linkID:      c.LinkID,
+			workspaceID: c.WorkspaceID,
+			bot:         c.IsBot,
+			data:        data,
+		})
+	}
+}
+
+// CloseStreams ends all event streams, so they do not hold up a graceful
+// shutdown.
+func (s *APIV1Service) CloseStreams() {
+	s.events.Close()
+}
+
+// linkEventsHandler streams the clicks of a link as they are recorded.
+func (s *APIV1Service) linkEventsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	s.streamClicks(w, r, func(c streamedClick) bool {
+		return c.linkID == link.ID
+	})
+}
+
+// workspaceEventsHandler streams the clicks of all links of the workspace
+// as they are recorded.
+func (s *APIV1Service) workspaceEventsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	workspace := s.contextGetPrincipal(r).Workspace
+
+	s.streamClicks(w, r, func(c streamedClick) bool {
+		return c.workspaceID == workspace.ID
+	})
+}
+
+// streamClicks pushes the clicks match reports true for as Server-Sent
+// Events named "click" until the client goes away. Bot clicks are left out
+// unless include_bots is set.
+//
+// Clients resuming with a Last-Event-ID header, or a last_event_id
+// parameter, first get the matching clicks they missed, as long as they
+// are among the last clickReplaySize clicks. Clients that cannot keep up
+// are disconnected and expected to resume.
+func (s *APIV1Service) streamClicks(w http.ResponseWriter, r *http.Request, match func(streamedClick) bool) {
+	includeBots := false
+	if v := r.URL.Query().Get("include_bots"); v != "" {
+		var err error
+		includeBots, err = strconv.ParseBool(v)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "include_bots must be true or false")
+			return
+		}
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		var err error
+		lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "invalid Last-Event-ID")
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	sub, missed := s.events.Subscribe(lastID, func(c streamedClick) bool {
+		return (includeBots || !c.bot) && match(c)
+	})
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// send writes an event, or a comment when e is nil, and flushes it.
+	send := func(e *events.Event[streamedClick]) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+			return err
+		}
+		var err error
+		if e == nil {
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		} else {
+			_, err = fmt.Fprintf(w, "id: %d\nevent: click\ndata: %s\n\n", e.ID, e.Value.data)
+		}
+		if err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	// Tell clients to reconnect quickly after a disconnect.
+	if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	for i := range missed {
+		if err := send(&missed[i]); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err := send(&e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := send(nil); err != nil {
+				return
+			}
+		}
+	}
+}
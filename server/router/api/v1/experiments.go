@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// experimentCookieTTL is how long a visitor keeps their assigned variant.
+const experimentCookieTTL = 90 * 24 * time.Hour
+
+func experimentCookieName(code string) string {
+	return "ls_ab_" + code
+}
+
+// assignVariant returns the variant of the running experiment the visitor
+// belongs to. Returning visitors keep their variant for as long as it
+// receives traffic; everyone else is assigned one by weight and remembered
+// with a cookie bound to the experiment ID.
+func (s *APIV1Service) assignVariant(w http.ResponseWriter, r *http.Request, link *database.Link) *database.Variant {
+	experiment := link.Experiment
+	name := experimentCookieName(link.Code)
+
+	if assigned, ok := s.readSignedCookie(r, name, experiment.ID); ok {
+		if variant, ok := experiment.Variant(assigned); ok {
+			return variant
+		}
+	}
+
+	variant := experiment.Pick()
+	s.setSignedCookie(w, name, variant.Name, experiment.ID, experimentCookieTTL)
+	return variant
+}
+
+func (s *APIV1Service) experimentHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	if link.Experiment == nil {
+		s.errorResponse(w, http.StatusNotFound, "link has no running experiment")
+		return
+	}
+
+	clicks, err := s.db.Clicks.VariantClicks(link.ID, link.Experiment.ID)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	total := 0
+	for _, n := range clicks {
+		total += n
+	}
+
+	variants := make([]map[string]any, 0, len(link.Experiment.Variants))
+	for _, v := range link.Experiment.Variants {
+		share := 0.0
+		if total > 0 {
+			share = float64(clicks[v.Name]) / float64(total)
+		}
+		variants = append(variants, map[string]any{
+			"name":   v.Name,
+			"url":    v.URL,
+			"weight": v.Weight,
+			"clicks": clicks[v.Name],
+			"share":  share,
+		})
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{
+		"id":           link.Experiment.ID,
+		"started_at":   link.Experiment.StartedAt,
+		"total_clicks": total,
+		"variants":     variants,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// putExperimentHandler starts an experiment or adjusts the variants of the
+// running one. Setting restart begins a new experiment, discarding existing
+// assignments and counts.
+func (s *APIV1Service) putExperimentHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	var input struct {
+		Variants []database.Variant `json:"variants"`
+		Restart  bool               `json:"restart"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	experiment := &database.Experiment{Variants: input.Variants}
+	if err := experiment.Validate(); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if link.Experiment != nil && !input.Restart {
+		experiment.ID = link.Experiment.ID
+		experiment.StartedAt = link.Experiment.StartedAt
+	} else {
+		experiment.ID, err = s.generateShortCode(8)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		experiment.StartedAt = time.Now().UTC()
+	}
+	link.Experiment = experiment
+
+	s.saveLink(w, link)
+}
+
+// promoteVariantHandler ends the experiment, making the winning variant the
+// default destination of the link.
+func (s *APIV1Service) promoteVariantHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	var input struct {
+		Variant string `json:"variant"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	if link.Experiment == nil {
+		s.errorResponse(w, http.StatusNotFound, "link has no running experiment")
+		return
+	}
+
+	var winner *database.Variant
+	for i := range link.Experiment.Variants {
+		if link.Experiment.Variants[i].Name == input.Variant {
+			winner = &link.Experiment.Variants[i]
+		}
+	}
+	if winner == nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, "unknown variant")
+		return
+	}
+
+	// The winner becomes the base URL, so the UTM parameters of the link
+	// are kept and later UTM changes build on it.
+	if err := link.SetDestination(winner.URL, link.UTM); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	link.Experiment = nil
+
+	s.saveLink(w, link)
+}
+
+// deleteExperimentHandler ends the experiment without changing the default
+// destination.
+func (s *APIV1Service) deleteExperimentHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	link.Experiment = nil
+
+	s.saveLink(w, link)
+}
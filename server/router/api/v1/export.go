@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/export"
+)
+
+// exportLinkClicksHandler streams the raw clicks of a link.
+func (s *APIV1Service) exportLinkClicksHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	s.exportClicks(w, r, database.ClickFilter{LinkID: link.ID}, "clicks-"+link.Code)
+}
+
+// exportClicksHandler streams the raw clicks of the links of the workspace.
+// Admins export the clicks of all links, or of the links of the workspace
+// given by workspace_id.
+func (s *APIV1Service) exportClicksHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var filter database.ClickFilter
+	name := "clicks"
+
+	if workspace := s.contextGetPrincipal(r).Workspace; workspace != nil {
+		filter.WorkspaceID = workspace.ID
+	} else if v := r.URL.Query().Get("workspace_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 1 {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "invalid workspace_id")
+			return
+		}
+		filter.WorkspaceID = id
+	}
+	if filter.WorkspaceID != 0 {
+		name = "clicks-workspace-" + strconv.Itoa(filter.WorkspaceID)
+	}
+
+	s.exportClicks(w, r, filter, name)
+}
+
+// exportClicks streams the clicks matching filter as CSV, NDJSON or Parquet
+// depending on format (default ndjson). The clicks can be narrowed to a
+// time range with from and to.
+//
+// The Export-Cursor response header carries the cursor of the export. Pass
+// it as cursor to the next export to get only the clicks recorded since.
+func (s *APIV1Service) exportClicks(w http.ResponseWriter, r *http.Request, filter database.ClickFilter, name string) {
+	qs := r.URL.Query()
+
+	format := export.NDJSON
+	if v := qs.Get("format"); v != "" {
+		format = v
+	}
+	if format != export.CSV && format != export.NDJSON && format != export.Parquet {
+		s.errorResponse(w, http.StatusUnprocessableEntity, export.ErrFormat.Error())
+		return
+	}
+
+	for _, bound := range []struct {
+		param string
+		t     *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := qs.Get(bound.param)
+		if v == "" {
+			continue
+		}
+		t, err := parseStatTime(v, time.UTC)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, bound.param+": "+err.Error())
+			return
+		}
+		*bound.t = t
+	}
+
+	cursor := qs.Get("cursor")
+	if _, err := export.ParseCursor(cursor); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	e, err := export.New(s.db.Clicks, filter, cursor)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, e.Cursor, format))
+	w.Header().Set("Export-Cursor", e.Cursor)
+
+	// Exports may take longer than the server's WriteTimeout; every write
+	// gets its own deadline instead.
+	dw := &deadlineWriter{w: w, rc: http.NewResponseController(w)}
+	buf := bufio.NewWriterSize(dw, 64<<10)
+
+	n, err := e.WriteTo(buf, format)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		log.Printf("export clicks after %d rows: %v", n, err)
+		// Abort the response so the client sees an incomplete download.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// deadlineWriter extends the write deadline of a response before every
+// write.
+type deadlineWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (d *deadlineWriter) Write(b []byte) (int, error) {
+	if err := d.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return 0, err
+	}
+	return d.w.Write(b)
+}
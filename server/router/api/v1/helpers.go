@@ -7,12 +7,23 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/mattn/go-sqlite3"
 )
 
+// isUniqueViolation reports whether err is a failed UNIQUE constraint.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 // generateShortCode generates a random short code.
@@ -121,3 +132,58 @@ func (s *APIV1Service) inputValidationErrors(w http.ResponseWriter, err error) {
 	}
 	s.errorResponse(w, http.StatusBadRequest, err.Error())
 }
+
+// clientIP returns the IP address of the client that sent r. X-Forwarded-For
+// is only honoured when the direct peer is a trusted proxy, and it is walked
+// from the right so that entries forged by the client are ignored.
+func (s *APIV1Service) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !s.isTrustedProxy(host) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		host = hop
+		if !s.isTrustedProxy(hop) {
+			break
+		}
+	}
+	return host
+}
+
+func (s *APIV1Service) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// readInt returns the integer query parameter key, or defaultValue when it is
+// missing. Values that are not integers are reported as an error.
+func (s *APIV1Service) readInt(qs url.Values, key string, defaultValue int) (int, error) {
+	v := qs.Get(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%s must be an integer value", key)
+	}
+	return i, nil
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"html/template"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// validateInterstitial checks that an interstitial can be served for a link
+// owned by workspaceID, which must own every selected snippet.
+func (s *APIV1Service) validateInterstitial(workspaceID int, in database.Interstitial) error {
+	if in.IsZero() {
+		return nil
+	}
+	if workspaceID == 0 {
+		return errors.New("interstitial requires a link owned by a workspace")
+	}
+	if len(in.SnippetIDs) == 0 {
+		return nil
+	}
+
+	snippets, err := s.db.Snippets.List(workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, id := range in.SnippetIDs {
+		if !slices.ContainsFunc(snippets, func(s *database.Snippet) bool { return s.ID == id }) {
+			return errors.New("unknown snippet id " + strconv.Itoa(id))
+		}
+	}
+	return nil
+}
+
+// serveInterstitial renders a page that fires the link's workspace snippets
+// and then continues to destination, by script after the configured delay
+// or by meta refresh when scripts are disabled. The Content-Security-Policy
+// only admits the hosts the snippets declare.
+func (s *APIV1Service) serveInterstitial(w http.ResponseWriter, link *database.Link, destination string) bool {
+	snippets, err := s.db.Snippets.List(link.WorkspaceID)
+	if err != nil {
+		log.Printf("interstitial %q: %v", link.Code, err)
+		return false
+	}
+	if ids := link.Interstitial.SnippetIDs; len(ids) > 0 {
+		snippets = slices.DeleteFunc(snippets, func(s *database.Snippet) bool { return !slices.Contains(ids, s.ID) })
+	}
+
+	nonce, err := s.generateShortCode(22)
+	if err != nil {
+		return false
+	}
+
+	sources := map[string][]string{"script-src": {"'nonce-" + nonce + "'"}}
+	for _, snippet := range snippets {
+		for directive, hosts := range snippet.CSPHosts() {
+			sources[directive] = append(sources[directive], hosts...)
+		}
+	}
+	csp := "default-src 'none'; style-src 'unsafe-inline'"
+	for _, directive := range slices.Sorted(maps.Keys(sources)) {
+		values := slices.Compact(slices.Sorted(slices.Values(sources[directive])))
+		csp += "; " + directive + " " + strings.Join(values, " ")
+	}
+	csp += "; frame-ancestors 'none'"
+
+	type snippetData struct {
+		Kind string
+		URL  string
+		Code template.JS
+	}
+	data := make([]snippetData, 0, len(snippets))
+	for _, snippet := range snippets {
+		// Inline code is written by the workspace that owns the link and
+		// cannot close its script element; see Snippet.Validate.
+		data = append(data, snippetData{Kind: snippet.Kind, URL: snippet.URL, Code: template.JS(snippet.Code)})
+	}
+
+	delay := link.Interstitial.DelayMS
+	s.renderPageWithCSP(w, http.StatusOK, "interstitial.html", map[string]any{
+		"Code":        link.Code,
+		"Destination": destination,
+		// Meta refresh only has second precision.
+		"Refresh":  strconv.Itoa((delay+999)/1000) + ";url=" + destination,
+		"DelayMS":  delay,
+		"Snippets": data,
+		"Nonce":    nonce,
+	}, csp)
+	return true
+}
+
+func (s *APIV1Service) createSnippetHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Name  string   `json:"name" validate:"required,max=100"`
+		Kind  string   `json:"kind" validate:"required"`
+		URL   string   `json:"url"`
+		Code  string   `json:"code"`
+		Hosts []string `json:"hosts" validate:"max=20"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	snippet := &database.Snippet{
+		WorkspaceID: s.contextGetPrincipal(r).Workspace.ID,
+		Name:        strings.TrimSpace(input.Name),
+		Kind:        input.Kind,
+		URL:         input.URL,
+		Code:        input.Code,
+		Hosts:       input.Hosts,
+	}
+	if err := snippet.Validate(); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = s.db.Snippets.Create(snippet)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusCreated, snippet)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) listSnippetsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	snippets, err := s.db.Snippets.List(s.contextGetPrincipal(r).Workspace.ID)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"snippets": snippets})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) deleteSnippetHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		s.errorResponse(w, http.StatusBadRequest, "invalid snippet id")
+		return
+	}
+
+	err = s.db.Snippets.Delete(s.contextGetPrincipal(r).Workspace.ID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.errorResponse(w, http.StatusNotFound, "snippet not found")
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,8 +1,15 @@
 package v1
 
 import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/julienschmidt/httprouter"
 	"golang.org/x/time/rate"
 )
 
@@ -12,6 +19,10 @@ func (s *APIV1Service) recoverPanic(next http.Handler) http.Handler {
 		defer func() {
 			// Use the builtin recover function to check if there has been a panic or not.
 			if err := recover(); err != nil {
+				// Let the server abort responses that are already underway.
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
 				w.Header().Set("Connection", "close")
 				s.errorResponse(w, http.StatusInternalServerError, "server encountered an issue")
 			}
@@ -49,3 +60,122 @@ func (s *APIV1Service) enableCORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticate identifies the caller from a bearer token, which is either the
+// configured admin token or a workspace token. Requests without an
+// Authorization header continue anonymously.
+func (s *APIV1Service) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			s.invalidAuthenticationToken(w)
+			return
+		}
+
+		if s.cfg.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) == 1 {
+			next.ServeHTTP(w, s.contextSetPrincipal(r, principal{Admin: true}))
+			return
+		}
+
+		workspace, err := s.db.Workspaces.GetByToken(token)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				s.invalidAuthenticationToken(w)
+				return
+			}
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, s.contextSetPrincipal(r, principal{Workspace: workspace}))
+	})
+}
+
+func (s *APIV1Service) invalidAuthenticationToken(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	s.errorResponse(w, http.StatusUnauthorized, "invalid or missing authentication token")
+}
+
+// requireAuthenticated restricts a handler to callers presenting the admin
+// token or a workspace token.
+func (s *APIV1Service) requireAuthenticated(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if s.contextGetPrincipal(r).IsAnonymous() {
+			s.invalidAuthenticationToken(w)
+			return
+		}
+		next(w, r, ps)
+	}
+}
+
+// requireAdmin restricts a handler to callers presenting the admin token.
+func (s *APIV1Service) requireAdmin(next httprouter.Handle) httprouter.Handle {
+	return s.requireAuthenticated(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if !s.contextGetPrincipal(r).Admin {
+			s.errorResponse(w, http.StatusForbidden, "admin token required")
+			return
+		}
+		next(w, r, ps)
+	})
+}
+
+// attemptLimiter rate limits attempts per key, such as password guesses for
+// a code from a single client IP. Idle keys are evicted in the background.
+type attemptLimiter struct {
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	clients map[string]*attemptClient
+}
+
+type attemptClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newAttemptLimiter(limit rate.Limit, burst int) *attemptLimiter {
+	l := &attemptLimiter{
+		limit:   limit,
+		burst:   burst,
+		clients: make(map[string]*attemptClient),
+	}
+
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+
+			l.mu.Lock()
+			for key, client := range l.clients {
+				if time.Since(client.lastSeen) > 15*time.Minute {
+					delete(l.clients, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}()
+
+	return l
+}
+
+// Allow reports whether another attempt for key may proceed.
+func (l *attemptLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, found := l.clients[key]
+	if !found {
+		client = &attemptClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = time.Now()
+
+	return client.limiter.Allow()
+}
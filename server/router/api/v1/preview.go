@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/joybiswas007/linkshort/internal/safety"
+)
+
+// destinationPreview describes one place a link may send visitors.
+type destinationPreview struct {
+	URL     string
+	Host    string
+	Verdict safety.Verdict
+}
+
+// previewHandler shows where a short code leads instead of following it. It
+// serves /<code>+ and /<code>?preview and, like resolveHandler, leaves
+// missing and expired links to the frontend.
+func (s *APIV1Service) previewHandler(w http.ResponseWriter, r *http.Request, code, suffix string) bool {
+	domain, err := s.hostDomain(r)
+	if err != nil {
+		log.Printf("preview %q: %v", r.Host, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	}
+
+	link, err := s.getActiveLink(domain.ID, code)
+	if err != nil {
+		if errors.Is(err, errLinkNotFound) || errors.Is(err, errLinkExpired) {
+			return redirectNotFound(w, r, domain)
+		}
+		log.Printf("preview %q: %v", code, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	}
+
+	if suffix != "" && !link.Forwarding.Path {
+		return redirectNotFound(w, r, domain)
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return true
+	}
+
+	if !s.canAccess(r, link) {
+		s.renderPage(w, http.StatusForbidden, "forbidden.html", map[string]any{"Code": link.Code})
+		return true
+	}
+
+	query := r.URL.Query()
+	query.Del("preview")
+
+	continueURL := "/" + link.Code + suffix
+	if encoded := query.Encode(); encoded != "" {
+		continueURL += "?" + encoded
+	}
+
+	locked := !s.isUnlocked(r, link)
+	data := map[string]any{
+		"Code":        link.Code,
+		"ContinueURL": continueURL,
+		"CreatedAt":   link.CreatedAt.UTC(),
+		// Destinations of password protected links stay hidden until unlocked.
+		"Locked": locked,
+		// The click count is only shown when the owner made it public.
+		"PublicStats": link.PublicStats,
+		"Clicks":      link.Clicks,
+	}
+	if link.ExpiresAt > 0 {
+		data["ExpiresAt"] = time.UnixMilli(int64(link.ExpiresAt)).UTC()
+	}
+
+	if !locked {
+		destination, err := link.Forwarding.Apply(link.OriginalURL, suffix, query)
+		if err != nil {
+			return false
+		}
+		data["Destination"] = s.previewDestination(destination)
+
+		// Routing rules and experiments may send visitors elsewhere.
+		seen := []string{destination}
+		var others []destinationPreview
+		add := func(u string) {
+			if !slices.Contains(seen, u) {
+				seen = append(seen, u)
+				others = append(others, s.previewDestination(u))
+			}
+		}
+		for _, rule := range link.Rules {
+			add(rule.Destination)
+		}
+		if link.Experiment != nil {
+			for _, v := range link.Experiment.Variants {
+				add(v.URL)
+			}
+		}
+		data["Others"] = others
+	}
+
+	s.renderPage(w, http.StatusOK, "preview.html", data)
+	return true
+}
+
+func (s *APIV1Service) previewDestination(rawURL string) destinationPreview {
+	p := destinationPreview{URL: rawURL, Verdict: s.safety.Evaluate(rawURL)}
+	if u, err := url.Parse(rawURL); err == nil {
+		p.Host = u.Host
+	}
+	return p
+}
@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+var errTooManyAttempts = errors.New("too many password attempts, try again later")
+
+// resolveHandler serves a short code requested directly in the browser,
+// looking it up among the links of the domain serving the request. It
+// reports whether it wrote a response; missing and expired links, and path
+// suffixes on links that do not forward them, go to the domain's 404
+// destination or are left to the frontend, which explains the error to the
+// visitor.
+func (s *APIV1Service) resolveHandler(w http.ResponseWriter, r *http.Request, code, suffix string) bool {
+	domain, err := s.hostDomain(r)
+	if err != nil {
+		log.Printf("resolve %q: %v", r.Host, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	}
+
+	link, err := s.getActiveLink(domain.ID, code)
+	if err != nil {
+		if errors.Is(err, errLinkNotFound) || errors.Is(err, errLinkExpired) {
+			return redirectNotFound(w, r, domain)
+		}
+		log.Printf("resolve %q: %v", code, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	}
+
+	if suffix != "" && !link.Forwarding.Path {
+		return redirectNotFound(w, r, domain)
+	}
+
+	setRedirectHeaders(w, link)
+
+	if !s.canAccess(r, link) {
+		s.renderPage(w, http.StatusForbidden, "forbidden.html", map[string]any{"Code": link.Code})
+		return true
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !s.isUnlocked(r, link) {
+			s.renderPasswordPrompt(w, http.StatusUnauthorized, link, "")
+			return true
+		}
+		if len(link.Rules) > 0 {
+			w.Header().Add("Vary", "Accept-Language, User-Agent")
+		}
+		// Only GET requests are recorded as clicks.
+		var click *database.Click
+		if r.Method == http.MethodGet {
+			click = s.newClick(r, link)
+		}
+		destination, routed := s.destinationFor(w, r, link, click)
+		destination, err = link.Forwarding.Apply(destination, suffix, r.URL.Query())
+		if err != nil {
+			return redirectNotFound(w, r, domain)
+		}
+		// clickID is the click ID of a recorded click, passed on to apps too.
+		var clickID string
+		if click != nil {
+			tagged := s.tagClickID(link, click, destination)
+			// The click ID of a dropped click would never be known.
+			if id := click.ClickID; s.clicks.Record(click) {
+				destination, clickID = tagged, id
+			}
+		}
+		if !routed && s.serveDeepLink(w, r, link, destination, suffix, clickID) {
+			return true
+		}
+		if link.Interstitial.Enabled && s.serveInterstitial(w, link, destination) {
+			return true
+		}
+		http.Redirect(w, r, destination, link.Redirect.StatusCode())
+	case http.MethodPost:
+		s.unlockFormHandler(w, r, link)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+	return true
+}
+
+// setRedirectHeaders applies the response headers configured for link.
+// Pages rendered instead of the redirect replace the caching headers.
+func setRedirectHeaders(w http.ResponseWriter, link *database.Link) {
+	h := w.Header()
+	if opts := link.Redirect; opts.MaxAge > 0 {
+		switch {
+		case link.ClickIDParam != "":
+			// Every redirect carries a new click ID.
+			h.Set("Cache-Control", "private, no-store")
+		case link.VariesByVisitor():
+			h.Set("Cache-Control", "private, max-age="+strconv.Itoa(opts.MaxAge))
+		default:
+			h.Set("Cache-Control", "public, max-age="+strconv.Itoa(opts.MaxAge))
+		}
+	}
+	if link.Redirect.NoIndex {
+		h.Set("X-Robots-Tag", "noindex")
+	}
+	if link.Redirect.ReferrerPolicy != "" {
+		h.Set("Referrer-Policy", link.Redirect.ReferrerPolicy)
+	}
+}
+
+// unlockFormHandler checks the password submitted from the server-rendered
+// prompt and sends the visitor back to the short URL once it is correct.
+func (s *APIV1Service) unlockFormHandler(w http.ResponseWriter, r *http.Request, link *database.Link) {
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+
+	ok, err := s.checkLinkPassword(r, link, r.PostFormValue("password"))
+	switch {
+	case errors.Is(err, errTooManyAttempts):
+		w.Header().Set("Retry-After", "60")
+		s.renderPasswordPrompt(w, http.StatusTooManyRequests, link, "Too many attempts. Try again in a minute.")
+	case err != nil:
+		log.Printf("unlock %q: %v", link.Code, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	case !ok:
+		s.renderPasswordPrompt(w, http.StatusUnauthorized, link, "Incorrect password.")
+	default:
+		s.setUnlockCookie(w, link)
+		http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+	}
+}
+
+// checkLinkPassword verifies password against link, limiting the number of
+// guesses a single client can make for each code.
+func (s *APIV1Service) checkLinkPassword(r *http.Request, link *database.Link, password string) (bool, error) {
+	if !link.PasswordProtected {
+		return true, nil
+	}
+	if !s.unlockLimiter.Allow(link.Code + "|" + s.clientIP(r)) {
+		return false, errTooManyAttempts
+	}
+	return link.PasswordMatches(password)
+}
+
+func (s *APIV1Service) renderPasswordPrompt(w http.ResponseWriter, status int, link *database.Link, message string) {
+	s.renderPage(w, status, "password.html", map[string]any{
+		"Code":  link.Code,
+		"Error": message,
+	})
+}
+
+// renderPage executes the named template and writes it with headers suited
+// to pages that must not be cached, framed or indexed.
+func (s *APIV1Service) renderPage(w http.ResponseWriter, status int, name string, data any) {
+	s.renderPageWithCSP(w, status, name, data,
+		"default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+}
+
+// renderPageWithCSP is renderPage with a custom Content-Security-Policy.
+func (s *APIV1Service) renderPageWithCSP(w http.ResponseWriter, status int, name string, data any, csp string) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Printf("render %s: %v", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-store")
+	h.Set("Content-Security-Policy", csp)
+	h.Set("X-Robots-Tag", "noindex")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
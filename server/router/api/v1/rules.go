@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/routing"
+)
+
+// visitorFromRequest describes the visitor that sent r for rule evaluation.
+// A country reported by the CDN takes precedence over the GeoIP database.
+func (s *APIV1Service) visitorFromRequest(r *http.Request) routing.Visitor {
+	var country string
+	if s.cfg.CountryHeader != "" {
+		country = r.Header.Get(s.cfg.CountryHeader)
+	}
+	if country == "" {
+		country = s.geoip.Lookup(s.clientIP(r)).Country
+	}
+	return routing.NewVisitor(country, r.UserAgent(), r.Header.Get("Accept-Language"), time.Now(), r.URL.Query())
+}
+
+// destinationFor returns the URL the visitor that sent r is redirected to and
+// whether a routing rule chose it. Routing rules take precedence; visitors
+// matching none of them are split between the variants of a running
+// experiment. The variant is recorded on click when it is set.
+func (s *APIV1Service) destinationFor(w http.ResponseWriter, r *http.Request, link *database.Link, click *database.Click) (string, bool) {
+	destination, index := routing.Resolve(link.Rules, s.visitorFromRequest(r), link.OriginalURL)
+	if index >= 0 {
+		return destination, true
+	}
+	if link.Experiment == nil {
+		return destination, false
+	}
+
+	// Assignments are per visitor, so shared caches must not store them.
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	variant := s.assignVariant(w, r, link)
+	if click != nil {
+		click.ExperimentID, click.Variant = link.Experiment.ID, variant.Name
+	}
+	return variant.URL, false
+}
+
+// rulesValidationError responds with the problems found by routing.Validate.
+func (s *APIV1Service) rulesValidationError(w http.ResponseWriter, err error) {
+	var verr routing.ValidationError
+	if errors.As(err, &verr) {
+		err = s.writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"errors": verr})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+}
+
+func (s *APIV1Service) validateRulesHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Rules []routing.Rule `json:"rules"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := routing.Validate(input.Rules); err != nil {
+		s.rulesValidationError(w, err)
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"valid": true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// dryRunRulesHandler reports which destination a synthetic request would be
+// sent to by the rules of a link.
+func (s *APIV1Service) dryRunRulesHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	var input struct {
+		Country        string    `json:"country"`
+		UserAgent      string    `json:"user_agent"`
+		AcceptLanguage string    `json:"accept_language"`
+		Time           time.Time `json:"time"`
+		Query          string    `json:"query"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query, err := url.ParseQuery(input.Query)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, "query must be a URL-encoded query string")
+		return
+	}
+	if input.Time.IsZero() {
+		input.Time = time.Now()
+	}
+
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	visitor := routing.NewVisitor(input.Country, input.UserAgent, input.AcceptLanguage, input.Time, query)
+	destination, index := routing.Resolve(link.Rules, visitor, link.OriginalURL)
+
+	result := map[string]any{
+		"destination": destination,
+		"visitor": map[string]any{
+			"country":  visitor.Country,
+			"os":       visitor.OS,
+			"device":   visitor.Device,
+			"language": visitor.Language,
+			"time":     visitor.Time,
+		},
+	}
+	if index >= 0 {
+		result["matched_rule"] = index
+	}
+
+	err = s.writeJSON(w, http.StatusOK, result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,197 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/stats"
+)
+
+// maxStatBuckets caps the length of a time series.
+const maxStatBuckets = 1500
+
+// defaultStatSpans are the ranges covered when from is not given.
+var defaultStatSpans = map[stats.Granularity]time.Duration{
+	stats.Minute: time.Hour,
+	stats.Hour:   48 * time.Hour,
+	stats.Day:    30 * 24 * time.Hour,
+	stats.Week:   12 * 7 * 24 * time.Hour,
+}
+
+// linkStats is the response of the link statistics endpoint.
+type linkStats struct {
+	Code        string                          `json:"code"`
+	From        time.Time                       `json:"from"`
+	To          time.Time                       `json:"to"`
+	Granularity stats.Granularity               `json:"granularity"`
+	Timezone    string                          `json:"timezone"`
+	Clicks      int                             `json:"clicks"`
+	Uniques     int                             `json:"uniques"`
+	IncludeBots bool                            `json:"include_bots"`
+	Bots        int                             `json:"bots,omitempty"`
+	Series      []database.StatPoint            `json:"series"`
+	Top         map[string][]database.StatCount `json:"top"`
+}
+
+// linkStatsHandler reports the clicks of a link in a time range: totals, a
+// time series in the requested granularity and timezone, and the most
+// frequent referrer hosts, countries, browsers, operating systems and
+// device types. A click is unique when it is the first one of its visitor
+// on a UTC day. The total of uniques is estimated from the visitor sketches
+// of the days overlapping the range; visitors cannot be recognized across
+// days, so those returning on several days count once per day.
+//
+// Clicks from bots, crawlers and link previews are left out unless
+// include_bots is set; they are then added to the clicks, counted in bots
+// and broken down by bot, but never unique.
+//
+// The range is extended to whole buckets of the series; breakdowns cover
+// the UTC days overlapping it. Once the retention policy has compacted
+// older clicks into daily or monthly rollups, those days and months count
+// in full, in the bucket where they start or in the first one.
+func (s *APIV1Service) linkStatsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	qs := r.URL.Query()
+
+	granularity := stats.Day
+	if v := qs.Get("granularity"); v != "" {
+		granularity, err = stats.ParseGranularity(v)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+	}
+
+	loc := time.UTC
+	if v := qs.Get("tz"); v != "" {
+		loc, err = time.LoadLocation(v)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "unknown timezone "+strconv.Quote(v))
+			return
+		}
+	}
+
+	to := time.Now()
+	if v := qs.Get("to"); v != "" {
+		to, err = parseStatTime(v, loc)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "to: "+err.Error())
+			return
+		}
+	}
+	from := to.Add(-defaultStatSpans[granularity])
+	if v := qs.Get("from"); v != "" {
+		from, err = parseStatTime(v, loc)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "from: "+err.Error())
+			return
+		}
+	}
+	if !from.Before(to) {
+		s.errorResponse(w, http.StatusUnprocessableEntity, "from must be before to")
+		return
+	}
+
+	from, to = granularity.Align(from, to, loc)
+	if granularity.Buckets(from, to) > maxStatBuckets {
+		s.errorResponse(w, http.StatusUnprocessableEntity,
+			"range has more than "+strconv.Itoa(maxStatBuckets)+" buckets, use a coarser granularity")
+		return
+	}
+
+	top := 10
+	if v := qs.Get("top"); v != "" {
+		top, err = strconv.Atoi(v)
+		if err != nil || top < 1 || top > 100 {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "top must be between 1 and 100")
+			return
+		}
+	}
+
+	includeBots := false
+	if v := qs.Get("include_bots"); v != "" {
+		includeBots, err = strconv.ParseBool(v)
+		if err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "include_bots must be true or false")
+			return
+		}
+	}
+
+	points, err := s.db.Clicks.Series(link.ID, granularity.Resolution(from, to, loc), from, to, includeBots)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := linkStats{
+		Code:        link.Code,
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		Timezone:    loc.String(),
+		IncludeBots: includeBots,
+		Series:      stats.Series(points, granularity, from, to, loc),
+		Top:         make(map[string][]database.StatCount),
+	}
+	for _, p := range resp.Series {
+		resp.Clicks += p.Clicks
+		resp.Bots += p.Bots
+	}
+
+	resp.Uniques, err = s.db.Clicks.Uniques(link.ID, from, to)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for _, dim := range database.Dimensions {
+		if dim == database.DimensionBot && !includeBots {
+			continue
+		}
+		counts, err := s.db.Clicks.Top(link.ID, dim, from, to, top, includeBots)
+		if err != nil {
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for i := range counts {
+			if counts[i].Value != "" {
+				continue
+			}
+			// Clicks without a referrer were typed in or opened from an app.
+			if dim == database.DimensionReferrer {
+				counts[i].Value = "direct"
+			} else {
+				counts[i].Value = "unknown"
+			}
+		}
+		resp.Top[dim] = counts
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"stats": resp})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// parseStatTime accepts RFC 3339 timestamps, dates in loc and unix seconds.
+func parseStatTime(v string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, v, loc); err == nil {
+		return t, nil
+	}
+	if unix, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	return time.Time{}, errors.New("must be an RFC 3339 timestamp, a date or unix seconds")
+}
@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+// requireWorkspace restricts a handler to callers presenting a workspace token.
+func (s *APIV1Service) requireWorkspace(next httprouter.Handle) httprouter.Handle {
+	return s.requireAuthenticated(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if s.contextGetPrincipal(r).Workspace == nil {
+			s.errorResponse(w, http.StatusForbidden, "workspace token required")
+			return
+		}
+		next(w, r, ps)
+	})
+}
+
+// resolveUTM combines the named workspace template with explicit values,
+// which take precedence.
+func (s *APIV1Service) resolveUTM(r *http.Request, templateName string, utm database.UTM) (database.UTM, error) {
+	if templateName == "" {
+		return utm, nil
+	}
+
+	workspace := s.contextGetPrincipal(r).Workspace
+	if workspace == nil {
+		return database.UTM{}, errors.New("utm_template requires a workspace token")
+	}
+
+	tpl, err := s.db.UTMTemplates.GetByName(workspace.ID, templateName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.UTM{}, errors.New("unknown utm_template")
+		}
+		return database.UTM{}, err
+	}
+
+	return tpl.UTM.Merge(utm), nil
+}
+
+func (s *APIV1Service) createUTMTemplateHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Name string       `json:"name" validate:"required,max=100"`
+		UTM  database.UTM `json:"utm"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	tpl := &database.UTMTemplate{
+		WorkspaceID: s.contextGetPrincipal(r).Workspace.ID,
+		Name:        strings.TrimSpace(input.Name),
+		UTM:         input.UTM.Normalize(),
+	}
+	if tpl.UTM.IsZero() {
+		s.errorResponse(w, http.StatusUnprocessableEntity, "a template needs at least one UTM value")
+		return
+	}
+
+	err = s.db.UTMTemplates.Create(tpl)
+	if err != nil {
+		if isUniqueViolation(err) {
+			s.errorResponse(w, http.StatusConflict, "a template with this name already exists")
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusCreated, tpl)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) listUTMTemplatesHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	templates, err := s.db.UTMTemplates.List(s.contextGetPrincipal(r).Workspace.ID)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"templates": templates})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) deleteUTMTemplateHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		s.errorResponse(w, http.StatusBadRequest, "invalid template id")
+		return
+	}
+
+	err = s.db.UTMTemplates.Delete(s.contextGetPrincipal(r).Workspace.ID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.errorResponse(w, http.StatusNotFound, "template not found")
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// campaignsHandler reports the campaigns the workspace's links belong to.
+func (s *APIV1Service) campaignsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	campaigns, err := s.db.UTMTemplates.Campaigns(s.contextGetPrincipal(r).Workspace.ID)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"campaigns": campaigns})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
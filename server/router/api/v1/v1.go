@@ -2,39 +2,223 @@
 package v1
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"net/netip"
 	"runtime"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/julienschmidt/httprouter"
+	"golang.org/x/time/rate"
 
 	"github.com/joybiswas007/linkshort/config"
+	"github.com/joybiswas007/linkshort/internal/clicks"
 	"github.com/joybiswas007/linkshort/internal/database"
+	"github.com/joybiswas007/linkshort/internal/events"
+	"github.com/joybiswas007/linkshort/internal/geoip"
+	"github.com/joybiswas007/linkshort/internal/ownership"
+	"github.com/joybiswas007/linkshort/internal/retention"
+	"github.com/joybiswas007/linkshort/internal/routing"
+	"github.com/joybiswas007/linkshort/internal/safety"
+	"github.com/joybiswas007/linkshort/internal/useragent"
 	"github.com/joybiswas007/linkshort/server/router/frontend"
 )
 
+var (
+	errLinkNotFound = errors.New("link not found for code")
+	errLinkExpired  = errors.New("link has expired")
+
+	errInvalidClickIDParam = errors.New("click_id_param must be 1-32 letters, digits, '-' or '_'")
+)
+
 // APIV1Service handles all API v1 endpoints and dependencies.
 type APIV1Service struct {
 	cfg *config.Config
 	db  database.Models
+
+	cookieKey      []byte
+	trustedProxies []netip.Prefix
+	unlockLimiter  *attemptLimiter
+	safety         *safety.Engine
+	verifier       *ownership.Verifier
+	clicks         *clicks.Recorder
+	compactor      *retention.Compactor
+	geoip          *geoip.DB
+	bots           *useragent.Bots
+	salt           visitorSalt
+	events         *events.Hub[streamedClick]
+	dashboards     dashboardCache
 }
 
 // NewAPIV1Service creates a new API v1 service instance.
-func NewAPIV1Service(cfg *config.Config, db database.Models) *APIV1Service {
-	return &APIV1Service{
-		cfg: cfg,
-		db:  db,
+func NewAPIV1Service(cfg *config.Config, db database.Models) (*APIV1Service, error) {
+	cookieKey := []byte(cfg.CookieSecret)
+	if len(cookieKey) == 0 {
+		cookieKey = make([]byte, 32)
+		rand.Read(cookieKey)
+	}
+
+	// The CIDR syntax has already been validated by the config package.
+	trustedProxies := make([]netip.Prefix, 0, len(cfg.TrustedProxies))
+	for _, cidr := range cfg.TrustedProxies {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			trustedProxies = append(trustedProxies, prefix.Masked())
+		}
+	}
+
+	geo, err := geoip.Open(cfg.GeoIP.Database, cfg.GeoIP.ASNDatabase)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip database: %w", err)
+	}
+
+	bots, err := useragent.NewBots(cfg.Clicks.BotRules)
+	if err != nil {
+		return nil, fmt.Errorf("load bot rules: %w", err)
+	}
+
+	s := &APIV1Service{
+		cfg:            cfg,
+		db:             db,
+		cookieKey:      cookieKey,
+		trustedProxies: trustedProxies,
+		// Five password attempts per code and client, then one per minute.
+		unlockLimiter: newAttemptLimiter(rate.Every(time.Minute), 5),
+		safety:        safety.New(cfg.BlockedDomains),
+		verifier:      ownership.New(cfg.DomainVerification.Resolver),
+		geoip:         geo,
+		bots:          bots,
+		events:        events.New[streamedClick](clickReplaySize, clickQueueSize),
 	}
+	s.clicks = clicks.New(db.Clicks, clicks.Options{
+		BufferSize:    cfg.Clicks.BufferSize,
+		BatchSize:     cfg.Clicks.BatchSize,
+		FlushInterval: cfg.Clicks.FlushInterval,
+		Overflow:      cfg.Clicks.Overflow,
+		Enrich:        s.enrichClick,
+		Written:       s.publishClicks,
+	})
+	s.compactor = retention.New(db.Clicks, retention.Policy{
+		RawDays:     cfg.Retention.RawDays,
+		DailyMonths: cfg.Retention.DailyMonths,
+	}, retention.Options{
+		BatchSize: cfg.Retention.BatchSize,
+		Interval:  cfg.Retention.Interval,
+	})
+
+	go s.verifyDomains()
+	go func() {
+		if err := geo.Watch(context.Background()); err != nil {
+			log.Printf("watch geoip databases: %v", err)
+		}
+	}()
+	go func() {
+		if err := bots.Watch(context.Background()); err != nil {
+			log.Printf("watch bot rules: %v", err)
+		}
+	}()
+
+	return s, nil
 }
 
-// RegisterRoutes configures and returns an HTTP handler with all API v1 routes.
+// Close stops compacting click data and writes the clicks that are still
+// queued. It is called once the servers have shut down.
+func (s *APIV1Service) Close(ctx context.Context) error {
+	if err := s.compactor.Close(ctx); err != nil {
+		return err
+	}
+	return s.clicks.Close(ctx)
+}
+
+// RegisterRoutes configures and returns an HTTP handler with all API v1
+// routes on a single listener, for deployments without an admin listener.
 func (s *APIV1Service) RegisterRoutes() http.Handler {
 	r := httprouter.New()
 
+	s.publicRoutes(r)
+	s.managementRoutes(r)
+	s.serveFrontend(r)
+
+	return s.publicMiddleware(r)
+}
+
+// PublicRoutes returns the HTTP handler of the public listener: short link
+// resolution, the SPA and the API it uses to create and open links.
+func (s *APIV1Service) PublicRoutes() http.Handler {
+	r := httprouter.New()
+
+	s.publicRoutes(r)
+	s.serveFrontend(r)
+
+	return s.publicMiddleware(r)
+}
+
+// AdminRoutes returns the HTTP handler of the admin listener: the whole API,
+// the admin UI, metrics under /debug/vars and pprof under /debug/pprof. It
+// is meant to be bound to localhost or an internal network.
+func (s *APIV1Service) AdminRoutes() http.Handler {
+	r := httprouter.New()
+
+	s.publicRoutes(r)
+	s.managementRoutes(r)
+	r.Handler(http.MethodGet, "/debug/*item", debugHandler())
+	frontend.Serve(r, frontend.Handlers{})
+
+	if s.cfg.IsProduction {
+		return s.countRequests("admin", s.recoverPanic(s.authenticate(r)))
+	}
+
+	return s.countRequests("admin", s.recoverPanic(s.enableCORS(s.authenticate(r))))
+}
+
+// publicRoutes registers the API routes visitors need.
+func (s *APIV1Service) publicRoutes(r *httprouter.Router) {
 	r.POST("/api/v1/links", s.shortLinkHandler)
 	r.GET("/api/v1/links/:code", s.linkByCodeHandler)
+	r.POST("/api/v1/links/:code/unlock", s.unlockLinkHandler)
+	r.POST("/api/v1/workspaces/session", s.workspaceSessionHandler)
+	r.DELETE("/api/v1/workspaces/session", s.deleteWorkspaceSessionHandler)
+
+	r.GET("/.well-known/*file", s.wellKnownHandler)
+}
+
+// managementRoutes registers the API routes managing links, workspaces and
+// domains.
+func (s *APIV1Service) managementRoutes(r *httprouter.Router) {
+	r.GET("/api/v1/links", s.requireAuthenticated(s.listLinksHandler))
+	r.PATCH("/api/v1/links/:code", s.requireAuthenticated(s.updateLinkHandler))
+	r.POST("/api/v1/links/:code/rules/dry-run", s.requireAuthenticated(s.dryRunRulesHandler))
+	r.GET("/api/v1/links/:code/experiment", s.requireAuthenticated(s.experimentHandler))
+	r.PUT("/api/v1/links/:code/experiment", s.requireAuthenticated(s.putExperimentHandler))
+	r.DELETE("/api/v1/links/:code/experiment", s.requireAuthenticated(s.deleteExperimentHandler))
+	r.POST("/api/v1/links/:code/experiment/promote", s.requireAuthenticated(s.promoteVariantHandler))
+	r.POST("/api/v1/rules/validate", s.requireAuthenticated(s.validateRulesHandler))
+	r.GET("/api/v1/utm-templates", s.requireWorkspace(s.listUTMTemplatesHandler))
+	r.POST("/api/v1/utm-templates", s.requireWorkspace(s.createUTMTemplateHandler))
+	r.DELETE("/api/v1/utm-templates/:id", s.requireWorkspace(s.deleteUTMTemplateHandler))
+	r.GET("/api/v1/snippets", s.requireWorkspace(s.listSnippetsHandler))
+	r.POST("/api/v1/snippets", s.requireWorkspace(s.createSnippetHandler))
+	r.DELETE("/api/v1/snippets/:id", s.requireWorkspace(s.deleteSnippetHandler))
+	r.GET("/api/v1/domains", s.requireAuthenticated(s.listDomainsHandler))
+	r.POST("/api/v1/domains", s.requireAuthenticated(s.createDomainHandler))
+	r.PATCH("/api/v1/domains/:host", s.requireAuthenticated(s.updateDomainHandler))
+	r.DELETE("/api/v1/domains/:host", s.requireAuthenticated(s.deleteDomainHandler))
+	r.POST("/api/v1/domains/:host/verify", s.requireAuthenticated(s.verifyDomainHandler))
+	r.GET("/api/v1/campaigns", s.requireWorkspace(s.campaignsHandler))
+	r.POST("/api/v1/conversions", s.requireAuthenticated(s.createConversionHandler))
+	r.GET("/api/v1/links/:code/conversions", s.requireAuthenticated(s.linkConversionsHandler))
+	r.GET("/api/v1/links/:code/stats", s.requireAuthenticated(s.linkStatsHandler))
+	r.GET("/api/v1/links/:code/events", s.requireAuthenticated(s.linkEventsHandler))
+	r.GET("/api/v1/events", s.requireWorkspace(s.workspaceEventsHandler))
+	r.GET("/api/v1/links/:code/clicks", s.requireAuthenticated(s.exportLinkClicksHandler))
+	r.GET("/api/v1/clicks", s.requireAuthenticated(s.exportClicksHandler))
+	r.POST("/api/v1/workspaces", s.requireAdmin(s.createWorkspaceHandler))
+	r.GET("/api/v1/admin/dashboard", s.requireAdmin(s.dashboardHandler))
 	r.GET("/api/v1/build-info", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 		runtimeVersion := runtime.Version()
 		bi := map[string]any{
@@ -47,21 +231,51 @@ func (s *APIV1Service) RegisterRoutes() http.Handler {
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	})
+}
 
-	// serve the frontend
-	frontend.Serve(r)
+// serveFrontend serves the SPA, resolving and previewing short codes
+// server-side.
+func (s *APIV1Service) serveFrontend(r *httprouter.Router) {
+	frontend.Serve(r, frontend.Handlers{
+		Root:    s.rootHandler,
+		Resolve: s.resolveHandler,
+		Preview: s.previewHandler,
+	})
+}
 
+// publicMiddleware wraps the handler of a listener facing visitors.
+func (s *APIV1Service) publicMiddleware(next http.Handler) http.Handler {
+	handler := s.rateLimit(s.authenticate(s.refuseUnverifiedHosts(next)))
 	if s.cfg.IsProduction {
-		return s.recoverPanic(s.rateLimit(r))
+		return s.countRequests("public", s.recoverPanic(handler))
 	}
 
-	return s.recoverPanic(s.enableCORS(s.rateLimit(r)))
+	return s.countRequests("public", s.recoverPanic(s.enableCORS(handler)))
 }
 
 func (s *APIV1Service) shortLinkHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	var input struct {
 		URL       string `json:"url" validate:"required,url"`
 		ExpiresAt int    `json:"expires_at,omitempty"`
+		Password  string `json:"password,omitempty"`
+
+		Rules      []routing.Rule      `json:"rules,omitempty"`
+		DeepLinks  database.DeepLinks  `json:"deep_links,omitzero"`
+		Forwarding database.Forwarding `json:"forwarding,omitzero"`
+
+		UTMTemplate string       `json:"utm_template,omitempty"`
+		UTM         database.UTM `json:"utm,omitzero"`
+
+		ClickIDParam string `json:"click_id_param,omitempty"`
+
+		Interstitial database.Interstitial `json:"interstitial,omitzero"`
+		PublicStats  bool                  `json:"public_stats,omitempty"`
+
+		Redirect database.RedirectOptions `json:"redirect,omitzero"`
+
+		// Domain is the host the link is created on, the one serving the
+		// request if empty.
+		Domain string `json:"domain,omitempty"`
 	}
 
 	err := s.readJSON(w, r, &input)
@@ -77,13 +291,57 @@ func (s *APIV1Service) shortLinkHandler(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	if err := routing.Validate(input.Rules); err != nil {
+		s.rulesValidationError(w, err)
+		return
+	}
+
+	if err := input.DeepLinks.Validate(); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if input.ClickIDParam != "" && !database.IsIdentifier(input.ClickIDParam) {
+		s.errorResponse(w, http.StatusUnprocessableEntity, errInvalidClickIDParam.Error())
+		return
+	}
+
+	utm, err := s.resolveUTM(r, input.UTMTemplate, input.UTM)
+	if err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	var domain *database.Domain
+	if input.Domain != "" {
+		domain, err = s.domainByHost(input.Domain)
+	} else {
+		domain, err = s.hostDomain(r)
+	}
+	if err != nil {
+		if errors.Is(err, errUnknownDomain) {
+			s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !domain.Verified() {
+		s.errorResponse(w, http.StatusUnprocessableEntity, errUnverifiedDomain.Error())
+		return
+	}
+	if !s.canUseDomain(r, domain) {
+		s.errorResponse(w, http.StatusForbidden, "domain belongs to another workspace")
+		return
+	}
+
 	code, err := s.generateShortCode(6)
 	if err != nil {
 		s.errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	exists, err := s.db.Links.Exists(code)
+	exists, err := s.db.Links.Exists(domain.ID, code)
 	if err != nil {
 		s.errorResponse(w, http.StatusBadRequest, err.Error())
 		return
@@ -100,14 +358,44 @@ func (s *APIV1Service) shortLinkHandler(w http.ResponseWriter, r *http.Request,
 	}
 
 	link := &database.Link{
-		Code:        shortCode,
-		ShortURL:    fmt.Sprintf("%s/%s", s.cfg.Domain, shortCode),
-		OriginalURL: input.URL,
+		Code:         shortCode,
+		ShortURL:     domain.ShortURL(shortCode),
+		DomainID:     domain.ID,
+		Domain:       domain.Host,
+		Rules:        input.Rules,
+		DeepLinks:    input.DeepLinks,
+		Forwarding:   input.Forwarding,
+		ClickIDParam: input.ClickIDParam,
+		Interstitial: input.Interstitial,
+		PublicStats:  input.PublicStats,
+		Redirect:     input.Redirect,
+	}
+
+	err = link.SetDestination(input.URL, utm)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if p := s.contextGetPrincipal(r); p.Workspace != nil {
+		link.WorkspaceID = p.Workspace.ID
+	}
+
+	if err := s.validateInterstitial(link.WorkspaceID, link.Interstitial); err != nil {
+		s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
 	}
 
 	if input.ExpiresAt > 0 {
 		link.ExpiresAt = input.ExpiresAt
 	}
+	domain.LinkDefaults.Apply(link)
+
+	err = link.SetPassword(input.Password)
+	if err != nil {
+		s.passwordError(w, err)
+		return
+	}
 
 	err = s.db.Links.Create(link)
 	if err != nil {
@@ -121,33 +409,208 @@ func (s *APIV1Service) shortLinkHandler(w http.ResponseWriter, r *http.Request,
 	}
 }
 
-func (s *APIV1Service) linkByCodeHandler(w http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+func (s *APIV1Service) linkByCodeHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	code := params.ByName("code")
 	if code == "" {
 		s.errorResponse(w, http.StatusBadRequest, "missing required path parameter: code")
 		return
 	}
 
-	exists, err := s.db.Links.Exists(code)
+	domain, err := s.getAPIDomain(w, r)
+	if err != nil {
+		return
+	}
+
+	link, err := s.getActiveLink(domain.ID, code)
 	if err != nil {
-		s.errorResponse(w, http.StatusBadRequest, "invalid code")
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if !exists {
-		s.errorResponse(w, http.StatusBadRequest, "link not found for code")
+	if !s.canAccess(r, link) {
+		s.errorResponse(w, http.StatusForbidden, "access to this link is restricted")
 		return
 	}
 
-	link, err := s.db.Links.GetByCode(code)
+	if !s.isUnlocked(r, link) {
+		s.errorResponse(w, http.StatusUnauthorized, "link is password protected")
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, link)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) updateLinkHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	var input struct {
+		URL       *string `json:"url" validate:"omitnil,url"`
+		ExpiresAt *int    `json:"expires_at"`
+		Password  *string `json:"password"`
+
+		AccessPolicy *database.AccessPolicy    `json:"access_policy"`
+		Rules        *[]routing.Rule           `json:"rules"`
+		DeepLinks    *database.DeepLinks       `json:"deep_links"`
+		Forwarding   *database.Forwarding      `json:"forwarding"`
+		UTM          *database.UTM             `json:"utm"`
+		ClickIDParam *string                   `json:"click_id_param"`
+		Interstitial *database.Interstitial    `json:"interstitial"`
+		PublicStats  *bool                     `json:"public_stats"`
+		Redirect     *database.RedirectOptions `json:"redirect"`
+	}
+
+	err := s.readJSON(w, r, &input)
 	if err != nil {
 		s.errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	nowMs := time.Now().UnixMilli()
-	if link.ExpiresAt > 0 && nowMs >= int64(link.ExpiresAt) {
-		s.errorResponse(w, http.StatusBadRequest, "link has expired")
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	link, err := s.getManagedLink(w, r, params.ByName("code"))
+	if err != nil {
+		return
+	}
+
+	if input.URL != nil || input.UTM != nil {
+		base, utm := link.OriginalURL, link.UTM
+		if link.BaseURL != "" {
+			base = link.BaseURL
+		}
+		if input.URL != nil {
+			base = *input.URL
+		}
+		if input.UTM != nil {
+			utm = *input.UTM
+		}
+		if err := link.SetDestination(base, utm); err != nil {
+			s.errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if input.ExpiresAt != nil {
+		link.ExpiresAt = max(*input.ExpiresAt, 0)
+	}
+	if input.Password != nil {
+		err = link.SetPassword(*input.Password)
+		if err != nil {
+			s.passwordError(w, err)
+			return
+		}
+	}
+	if input.AccessPolicy != nil {
+		if input.AccessPolicy.WorkspaceOnly && link.WorkspaceID == 0 {
+			s.errorResponse(w, http.StatusUnprocessableEntity, "workspace_only requires a link owned by a workspace")
+			return
+		}
+		link.AccessPolicy = *input.AccessPolicy
+	}
+	if input.Rules != nil {
+		if err := routing.Validate(*input.Rules); err != nil {
+			s.rulesValidationError(w, err)
+			return
+		}
+		link.Rules = *input.Rules
+	}
+	if input.DeepLinks != nil {
+		if err := input.DeepLinks.Validate(); err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		link.DeepLinks = *input.DeepLinks
+	}
+	if input.Forwarding != nil {
+		link.Forwarding = *input.Forwarding
+	}
+	if input.ClickIDParam != nil {
+		if *input.ClickIDParam != "" && !database.IsIdentifier(*input.ClickIDParam) {
+			s.errorResponse(w, http.StatusUnprocessableEntity, errInvalidClickIDParam.Error())
+			return
+		}
+		link.ClickIDParam = *input.ClickIDParam
+	}
+	if input.Interstitial != nil {
+		if err := s.validateInterstitial(link.WorkspaceID, *input.Interstitial); err != nil {
+			s.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		link.Interstitial = *input.Interstitial
+	}
+	if input.PublicStats != nil {
+		link.PublicStats = *input.PublicStats
+	}
+	if input.Redirect != nil {
+		link.Redirect = *input.Redirect
+	}
+
+	s.saveLink(w, link)
+}
+
+// listLinksHandler lists the caller's links, optionally filtered by UTM
+// values. The admin sees the links of every workspace.
+func (s *APIV1Service) listLinksHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	qs := r.URL.Query()
+
+	utm := database.UTM{
+		Source:   qs.Get("utm_source"),
+		Medium:   qs.Get("utm_medium"),
+		Campaign: qs.Get("utm_campaign"),
+	}.Normalize()
+
+	filter := database.LinkFilter{
+		UTMSource:   utm.Source,
+		UTMMedium:   utm.Medium,
+		UTMCampaign: utm.Campaign,
+	}
+	if p := s.contextGetPrincipal(r); p.Workspace != nil {
+		filter.WorkspaceID = p.Workspace.ID
+	}
+	if host := qs.Get("domain"); host != "" {
+		domain, err := s.domainByHost(host)
+		if err != nil {
+			if errors.Is(err, errUnknownDomain) {
+				s.errorResponse(w, http.StatusNotFound, err.Error())
+				return
+			}
+			s.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		filter.DomainID = domain.ID
+	}
+
+	var err error
+	if filter.Limit, err = s.readInt(qs, "limit", 50); err != nil || filter.Limit < 1 || filter.Limit > 100 {
+		s.errorResponse(w, http.StatusBadRequest, "limit must be between 1 and 100")
+		return
+	}
+	if filter.Offset, err = s.readInt(qs, "offset", 0); err != nil || filter.Offset < 0 {
+		s.errorResponse(w, http.StatusBadRequest, "offset must be a positive integer")
+		return
+	}
+
+	links, err := s.db.Links.List(filter)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"links": links})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// saveLink persists changes to link and responds with the updated link.
+func (s *APIV1Service) saveLink(w http.ResponseWriter, link *database.Link) {
+	err := s.db.Links.Update(link)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -156,3 +619,88 @@ func (s *APIV1Service) linkByCodeHandler(w http.ResponseWriter, _ *http.Request,
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+// passwordError responds with the error of Link.SetPassword.
+func (s *APIV1Service) passwordError(w http.ResponseWriter, err error) {
+	if errors.Is(err, database.ErrPasswordTooLong) {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.errorResponse(w, http.StatusInternalServerError, err.Error())
+}
+
+func (s *APIV1Service) unlockLinkHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	var input struct {
+		Password string `json:"password" validate:"required"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	domain, err := s.getAPIDomain(w, r)
+	if err != nil {
+		return
+	}
+
+	link, err := s.getActiveLink(domain.ID, params.ByName("code"))
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !s.canAccess(r, link) {
+		s.errorResponse(w, http.StatusForbidden, "access to this link is restricted")
+		return
+	}
+
+	ok, err := s.checkLinkPassword(r, link, input.Password)
+	if err != nil {
+		if errors.Is(err, errTooManyAttempts) {
+			w.Header().Set("Retry-After", "60")
+			s.errorResponse(w, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		s.errorResponse(w, http.StatusUnauthorized, "incorrect password")
+		return
+	}
+
+	s.setUnlockCookie(w, link)
+
+	err = s.writeJSON(w, http.StatusOK, link)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// getActiveLink returns the link for code on a domain, or errLinkNotFound /
+// errLinkExpired when it cannot be resolved.
+func (s *APIV1Service) getActiveLink(domainID int, code string) (*database.Link, error) {
+	link, err := s.db.Links.GetByCode(domainID, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errLinkNotFound
+		}
+		return nil, err
+	}
+
+	nowMs := time.Now().UnixMilli()
+	if link.ExpiresAt > 0 && nowMs >= int64(link.ExpiresAt) {
+		return nil, errLinkExpired
+	}
+
+	return link, nil
+}
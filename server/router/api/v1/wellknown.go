@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// wellKnownHandler serves the app association files generated from config.
+// Other /.well-known paths are answered with 404 instead of falling through
+// to the SPA, since verifiers treat an HTML response as a broken file.
+// Ownership tokens are never served here: the owner of a custom domain must
+// serve them, or any host routed to this instance would pass verification.
+func (s *APIV1Service) wellKnownHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	var doc any
+
+	switch params.ByName("file") {
+	case "/apple-app-site-association":
+		if len(s.cfg.AppLinks.Apple.AppIDs) > 0 {
+			doc = s.appleAppSiteAssociation()
+		}
+	case "/assetlinks.json":
+		if len(s.cfg.AppLinks.Android) > 0 {
+			doc = s.assetLinks()
+		}
+	}
+
+	if doc == nil {
+		s.errorResponse(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	err := s.writeJSON(w, http.StatusOK, doc)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) appleAppSiteAssociation() map[string]any {
+	apple := s.cfg.AppLinks.Apple
+
+	paths := apple.Paths
+	if len(paths) == 0 {
+		paths = []string{"/*"}
+	}
+	components := make([]map[string]string, 0, len(paths))
+	for _, p := range paths {
+		components = append(components, map[string]string{"/": p})
+	}
+
+	return map[string]any{
+		"applinks": map[string]any{
+			"details": []map[string]any{{
+				"appIDs":     apple.AppIDs,
+				"components": components,
+			}},
+		},
+	}
+}
+
+func (s *APIV1Service) assetLinks() []map[string]any {
+	statements := make([]map[string]any, 0, len(s.cfg.AppLinks.Android))
+	for _, app := range s.cfg.AppLinks.Android {
+		statements = append(statements, map[string]any{
+			"relation": []string{"delegate_permission/common.handle_all_urls"},
+			"target": map[string]any{
+				"namespace":                "android_app",
+				"package_name":             app.PackageName,
+				"sha256_cert_fingerprints": app.SHA256CertFingerprints,
+			},
+		})
+	}
+	return statements
+}
@@ -0,0 +1,88 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/joybiswas007/linkshort/internal/database"
+)
+
+func (s *APIV1Service) createWorkspaceHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Name string `json:"name" validate:"required,max=100"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	workspace := &database.Workspace{Name: input.Name}
+
+	token, err := s.db.Workspaces.Create(workspace)
+	if err != nil {
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The token is only ever shown once.
+	err = s.writeJSON(w, http.StatusCreated, map[string]any{"workspace": workspace, "token": token})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// workspaceSessionHandler exchanges a workspace token for a signed cookie, so
+// browsers can open links restricted to workspace members.
+func (s *APIV1Service) workspaceSessionHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Token string `json:"token" validate:"required"`
+	}
+
+	err := s.readJSON(w, r, &input)
+	if err != nil {
+		s.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	if err := validate.Struct(&input); err != nil {
+		s.inputValidationErrors(w, err)
+		return
+	}
+
+	workspace, err := s.db.Workspaces.GetByToken(input.Token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.invalidAuthenticationToken(w)
+			return
+		}
+		s.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.setWorkspaceCookie(w, workspace)
+
+	err = s.writeJSON(w, http.StatusOK, map[string]any{"workspace": workspace})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *APIV1Service) deleteWorkspaceSessionHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	s.clearCookie(w, workspaceCookieName)
+	w.WriteHeader(http.StatusNoContent)
+}
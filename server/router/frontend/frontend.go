@@ -17,11 +17,33 @@ import (
 //go:embed "dist"
 var embeddedFiles embed.FS
 
+// Resolver handles a short code requested directly in the browser. The first
+// path segment is the code and suffix holds the escaped remainder of the
+// path, including its leading slash. It reports whether it wrote a response;
+// when it did not, the SPA is served instead.
+type Resolver func(w http.ResponseWriter, r *http.Request, code, suffix string) bool
+
+// Handlers are the server-side handlers the frontend defers to. Each reports
+// whether it wrote a response; when it did not, the SPA is served instead.
+type Handlers struct {
+	// Root handles the bare domain.
+	Root func(w http.ResponseWriter, r *http.Request) bool
+	// Resolve handles paths that are not embedded assets.
+	Resolve Resolver
+	// Preview handles codes ending in "+" or requested with a "preview"
+	// query parameter.
+	Preview Resolver
+}
+
 // Serve sets up the frontend routes to serve embedded static files.
-func Serve(r *httprouter.Router) {
+// Paths that are not embedded assets are offered to h.
+func Serve(r *httprouter.Router, h Handlers) {
 	distFS := getFileSystem("dist")
 
 	r.GET("/", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if h.Root != nil && h.Root(w, r) {
+			return
+		}
 		serveIndex(distFS, w, r)
 	})
 
@@ -29,23 +51,41 @@ func Serve(r *httprouter.Router) {
 	// It serves static assets when they exist, otherwise falls back to index.html
 	// to allow React Router to handle the route.
 	r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.HasPrefix(r.RequestURI, "/api") {
-			assetPath := strings.TrimPrefix(r.RequestURI, "/")
-			if assetPath == "" {
-				assetPath = "index.html"
+		// API routes not served by this listener, e.g. management routes on
+		// the public listener.
+		if strings.HasPrefix(r.RequestURI, "/api/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		assetPath := strings.TrimPrefix(r.RequestURI, "/")
+		if assetPath == "" {
+			assetPath = "index.html"
+		}
+		asset, err := distFS.Open(assetPath)
+		if err != nil {
+			code, suffix, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+			if suffix != "" {
+				suffix = "/" + suffix
+			}
+			handler := h.Resolve
+			if c, ok := strings.CutSuffix(code, "+"); ok {
+				code, handler = c, h.Preview
+			} else if r.URL.Query().Has("preview") {
+				handler = h.Preview
 			}
-			asset, err := distFS.Open(assetPath)
-			if err != nil {
-				// Asset not found, serve index.html for client-side routing
-				serveIndex(distFS, w, r)
+			if handler != nil && code != "" && handler(w, r, code, suffix) {
 				return
 			}
-			defer asset.Close()
+			// Asset not found, serve index.html for client-side routing
+			serveIndex(distFS, w, r)
+			return
+		}
+		defer asset.Close()
 
-			stat, _ := asset.Stat()
+		stat, _ := asset.Stat()
 
-			http.ServeContent(w, r, path.Base(r.RequestURI), stat.ModTime(), asset)
-		}
+		http.ServeContent(w, r, path.Base(r.RequestURI), stat.ModTime(), asset)
 	})
 }
 
@@ -2,39 +2,108 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/joybiswas007/linkshort/config"
+	"github.com/joybiswas007/linkshort/internal/certstore"
 	"github.com/joybiswas007/linkshort/internal/database"
 	v1 "github.com/joybiswas007/linkshort/server/router/api/v1"
 )
 
-// Server holds the HTTP server configuration and dependencies.
-type Server struct {
-	port   int
-	models database.Models
+// Servers are the HTTP servers of the application, sharing one API service.
+type Servers struct {
+	// Public serves short links, the SPA and the API it uses on cfg.Port.
+	// When it has a TLSConfig it must be started with ListenAndServeTLS("", "").
+	Public *http.Server
+	// Admin serves the management API, admin UI, metrics and pprof on
+	// cfg.AdminAddr. It is nil when no admin address is configured, in which
+	// case Public serves the management API as well.
+	Admin *http.Server
+	// Redirect sends plain HTTP requests to Public. It is nil unless TLS and
+	// a redirect port are configured.
+	Redirect *http.Server
+
+	api *v1.APIV1Service
 }
 
-// NewServer creates and configures a new HTTP server instance.
-func NewServer(cfg *config.Config, db *sql.DB) *http.Server {
-	NewServer := &Server{
-		port:   cfg.Port,
-		models: database.NewModels(db),
+// All returns the servers that are configured.
+func (s *Servers) All() []*http.Server {
+	all := []*http.Server{s.Public}
+	if s.Admin != nil {
+		all = append(all, s.Admin)
+	}
+	if s.Redirect != nil {
+		all = append(all, s.Redirect)
 	}
+	return all
+}
+
+// Close finishes the background work of the servers, such as writing
+// queued clicks. It must be called after the servers have shut down.
+func (s *Servers) Close(ctx context.Context) error {
+	return s.api.Close(ctx)
+}
 
-	v1Server := v1.NewAPIV1Service(cfg, NewServer.models)
+// NewServers creates and configures the HTTP servers. Certificates of the
+// public server are reloaded as they change.
+func NewServers(cfg *config.Config, db *sql.DB) (*Servers, error) {
+	v1Server, err := v1.NewAPIV1Service(cfg, database.NewModels(db))
+	if err != nil {
+		return nil, err
+	}
 
-	// Declare Server config.
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", NewServer.port),
-		Handler:      v1Server.RegisterRoutes(),
+	public := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server
+	servers := &Servers{Public: public, api: v1Server}
+
+	if cfg.AdminAddr != "" {
+		public.Handler = hsts(cfg.TLS.HSTS, v1Server.PublicRoutes())
+		servers.Admin = &http.Server{
+			Addr:        cfg.AdminAddr,
+			Handler:     v1Server.AdminRoutes(),
+			IdleTimeout: time.Minute,
+			ReadTimeout: 10 * time.Second,
+			// CPU profiles and traces run for 30 seconds by default.
+			WriteTimeout: 2 * time.Minute,
+		}
+	} else {
+		public.Handler = hsts(cfg.TLS.HSTS, v1Server.RegisterRoutes())
+	}
+
+	if cfg.TLS.Enabled() {
+		store, err := certstore.New(certificatePairs(cfg.TLS))
+		if err != nil {
+			return nil, err
+		}
+		public.TLSConfig, err = newTLSConfig(cfg.TLS, store)
+		if err != nil {
+			return nil, err
+		}
+
+		go func() {
+			if err := store.Watch(context.Background()); err != nil {
+				log.Printf("watch certificates: %v", err)
+			}
+		}()
+	}
+
+	servers.Redirect = newRedirectServer(cfg)
+
+	// Event streams only end when their clients go away, so end them when
+	// a graceful shutdown starts.
+	for _, srv := range servers.All() {
+		srv.RegisterOnShutdown(v1Server.CloseStreams)
+	}
+
+	return servers, nil
 }
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joybiswas007/linkshort/config"
+	"github.com/joybiswas007/linkshort/internal/certstore"
+)
+
+// newTLSConfig builds the TLS configuration of the server, selecting
+// certificates from store by SNI.
+func newTLSConfig(cfg config.TLS, store *certstore.Store) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: store.GetCertificate,
+	}
+
+	if cfg.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		// Only suites Go considers secure are accepted.
+		ids := make(map[string]uint16)
+		for _, suite := range tls.CipherSuites() {
+			ids[suite.Name] = suite.ID
+		}
+		for _, name := range cfg.CipherSuites {
+			id, ok := ids[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// certificatePairs lists the default certificate first, so it is served
+// when no other certificate matches.
+func certificatePairs(cfg config.TLS) []certstore.Pair {
+	pairs := []certstore.Pair{{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile}}
+	for _, c := range cfg.Certificates {
+		pairs = append(pairs, certstore.Pair{CertFile: c.CertFile, KeyFile: c.KeyFile})
+	}
+	return pairs
+}
+
+// hsts sets the Strict-Transport-Security header on responses to HTTPS
+// requests.
+func hsts(cfg config.HSTS, next http.Handler) http.Handler {
+	if cfg.MaxAge <= 0 {
+		return next
+	}
+
+	value := "max-age=" + strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	if cfg.IncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if cfg.Preload {
+		value += "; preload"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newRedirectServer returns a plain HTTP server redirecting every request
+// to the HTTPS server, or nil when TLS or the redirect listener is not
+// configured.
+func newRedirectServer(cfg *config.Config) *http.Server {
+	if !cfg.TLS.Enabled() || cfg.TLS.RedirectPort == 0 {
+		return nil
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if cfg.Port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6 literal
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.TLS.RedirectPort),
+		Handler:      handler,
+		IdleTimeout:  time.Minute,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+}